@@ -5,8 +5,15 @@ import "errors"
 const (
 	DefaultKeyPrefix = "wx:"
 
-	BizAccessToken = "ak"
-	BizJSTicket    = "js_ticket"
+	BizAccessToken           = "ak"
+	BizAccessTokenLock       = "ak_lock"
+	BizJSTicket              = "js_ticket"
+	BizJSTicketLock          = "js_ticket_lock"
+	BizCardTicket            = "card_ticket"
+	BizComponentVerifyTicket = "component_verify_ticket"
+	BizComponentAccessToken  = "component_ak"
+	BizRateLimit             = "rate_limit"
+	BizSession               = "session"
 )
 
 var (
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,16 +18,39 @@ func TestDummyCache(t *testing.T) {
 	})
 }
 
-// func TestRedisCache(t *testing.T) {
-// 	testCache(t, func() w.Cache {
-// 		s, err := miniredis.Run()
-// 		assert.NoError(t, err)
-// 		r := redis.NewClient(&redis.Options{
-// 			Addr: s.Addr(),
-// 		})
-// 		return caches.NewRedisCache(r, "")
-// 	})
-// }
+func TestRedisCache(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	// miniredis does not expire keys by itself
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	go func() {
+		for range ticker.C {
+			s.FastForward(100 * time.Millisecond)
+		}
+	}()
+
+	testCache(t, func() caches.Cache {
+		s.FlushAll()
+		r := redis.NewClient(&redis.Options{
+			Addr: s.Addr(),
+		})
+		return caches.NewRedisCache(r, nil)
+	})
+
+	r := redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	})
+	cache := caches.NewRedisCache(r, nil)
+	err := cache.Set("myAppId", "myBiz", []byte("myValue"), 10)
+	assert.NoError(t, err)
+	assert.True(t, s.Exists(caches.DefaultKeyPrefix+"myAppId:myBiz"))
+
+	cache = caches.NewRedisCache(r, caches.NewKeyBuilder(caches.KeyConfig{Prefix: "prefix:"}))
+	err = cache.Set("myAppId", "myBiz", []byte("myValue"), 10)
+	assert.NoError(t, err)
+	assert.True(t, s.Exists("prefix:myAppId:myBiz"))
+}
 
 func testCache(t *testing.T, f CacheFactory) {
 	testCacheGet(t, f)
@@ -0,0 +1,157 @@
+package caches
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type fileCacheItem struct {
+	Value     []byte    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type fileCache struct {
+	dir  string
+	keys KeyBuilder
+}
+
+// NewFileCache creates a `Cache` persisting items in the given directory,
+// which can be shared by processes on the same host.
+// Each item is stored in its own file along with its expiry,
+// files are replaced atomically and `Add`, `Delete` are guarded by file locks.
+// `DefaultKeyBuilder` is used if no `KeyBuilder` is given.
+func NewFileCache(dir string, keys KeyBuilder) Cache {
+	if keys == nil {
+		keys = DefaultKeyBuilder
+	}
+	return &fileCache{
+		dir:  dir,
+		keys: keys,
+	}
+}
+
+func (c *fileCache) Get(appId string, key string) ([]byte, error) {
+	item, err := c.read(c.getPath(appId, key))
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrKeyNotFound
+	}
+	return item.Value, nil
+}
+
+func (c *fileCache) Set(appId string, key string, value []byte, expiresIn int) error {
+	path := c.getPath(appId, key)
+	unlock, err := c.lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return c.write(path, value, expiresIn)
+}
+
+func (c *fileCache) Add(appId string, key string, value []byte, expiresIn int) error {
+	path := c.getPath(appId, key)
+	unlock, err := c.lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	item, err := c.read(path)
+	if err != nil {
+		return err
+	}
+	if item != nil {
+		return ErrKeyExisted
+	}
+	return c.write(path, value, expiresIn)
+}
+
+func (c *fileCache) Delete(appId string, key string, value []byte) error {
+	path := c.getPath(appId, key)
+	unlock, err := c.lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	item, err := c.read(path)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return nil
+	}
+	if value != nil && string(item.Value) != string(value) {
+		return ErrValueNotMatched
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (c *fileCache) getPath(appId string, key string) string {
+	return filepath.Join(c.dir, url.QueryEscape(c.keys.BuildKey(appId, key)))
+}
+
+func (c *fileCache) lock(path string) (func(), error) {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return nil, err
+	}
+	return lockFile(path + ".lock")
+}
+
+// Returns nil if the item does not exist or has expired
+func (c *fileCache) read(path string) (*fileCacheItem, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	item := &fileCacheItem{}
+	if err := json.Unmarshal(data, item); err != nil {
+		// A corrupted file is treated as missing, it will be overwritten
+		return nil, nil
+	}
+	if !item.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	return item, nil
+}
+
+// Writes to a temporary file then renames it, so readers never see a partial file
+func (c *fileCache) write(path string, value []byte, expiresIn int) error {
+	data, err := json.Marshal(&fileCacheItem{
+		Value:     value,
+		ExpiresAt: time.Now().Add(time.Duration(expiresIn) * time.Second),
+	})
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(c.dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
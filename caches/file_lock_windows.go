@@ -0,0 +1,34 @@
+//go:build windows
+// +build windows
+
+package caches
+
+import (
+	"os"
+	"time"
+)
+
+// A lock file older than this is considered left by a dead process
+const staleLockTimeout = 30 * time.Second
+
+// Acquires a lock by exclusively creating the given file, returns the function to release it
+func lockFile(path string) (func(), error) {
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() {
+				os.Remove(path)
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockTimeout {
+			os.Remove(path)
+			continue
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
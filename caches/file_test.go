@@ -0,0 +1,58 @@
+package caches_test
+
+import (
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileCache(t *testing.T) {
+	testCache(t, func() caches.Cache {
+		return caches.NewFileCache(t.TempDir(), nil)
+	})
+}
+
+func TestFileCachePersistence(t *testing.T) {
+	dir := t.TempDir()
+
+	cache := caches.NewFileCache(dir, nil)
+	err := cache.Set("myAppId", "myBiz", []byte("myValue"), 10)
+	assert.NoError(t, err)
+
+	cache = caches.NewFileCache(dir, nil)
+	got, err := cache.Get("myAppId", "myBiz")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("myValue"), got)
+
+	files, _ := ioutil.ReadDir(dir)
+	names := []string{}
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	assert.Contains(t, names, "wx%3AmyAppId%3AmyBiz")
+}
+
+func TestFileCacheConcurrentAdd(t *testing.T) {
+	dir := t.TempDir()
+
+	var added int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each instance stands for a process
+			cache := caches.NewFileCache(dir, nil)
+			if cache.Add("myAppId", "lock", []byte("value"), 10) == nil {
+				atomic.AddInt32(&added, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), added)
+}
@@ -0,0 +1,50 @@
+package caches
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// KeyBuilder builds the key of an item stored in a cache backend
+type KeyBuilder interface {
+	BuildKey(appId string, biz string) string
+}
+
+type KeyConfig struct {
+	Prefix    string // Prefix of every key, default value is `DefaultKeyPrefix`
+	Namespace string // Namespace following the prefix, used to isolate environments or tenants sharing a store
+	MaxLength int    // Keys longer than it are hashed, keys are never hashed if not given
+}
+
+type keyBuilder struct {
+	prefix    string
+	maxLength int
+}
+
+// NewKeyBuilder creates a `KeyBuilder` building keys like `{prefix}{namespace}:{appId}:{biz}`.
+// When a key is longer than `MaxLength`, the `{appId}:{biz}` part is replaced by its SHA-256 digest.
+func NewKeyBuilder(conf KeyConfig) KeyBuilder {
+	if conf.Prefix == "" {
+		conf.Prefix = DefaultKeyPrefix
+	}
+	prefix := conf.Prefix
+	if conf.Namespace != "" {
+		prefix += conf.Namespace + ":"
+	}
+	return &keyBuilder{
+		prefix:    prefix,
+		maxLength: conf.MaxLength,
+	}
+}
+
+func (b *keyBuilder) BuildKey(appId string, biz string) string {
+	key := appId + ":" + biz
+	if b.maxLength > 0 && len(b.prefix)+len(key) > b.maxLength {
+		sum := sha256.Sum256([]byte(key))
+		key = hex.EncodeToString(sum[:])
+	}
+	return b.prefix + key
+}
+
+// DefaultKeyBuilder builds keys like `wx:{appId}:{biz}`
+var DefaultKeyBuilder = NewKeyBuilder(KeyConfig{})
@@ -0,0 +1,52 @@
+package caches_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyBuilder(t *testing.T) {
+	assert.Equal(t, "wx:app-id:ak", caches.DefaultKeyBuilder.BuildKey("app-id", caches.BizAccessToken))
+
+	b := caches.NewKeyBuilder(caches.KeyConfig{Prefix: "prefix:"})
+	assert.Equal(t, "prefix:app-id:ak", b.BuildKey("app-id", caches.BizAccessToken))
+
+	b = caches.NewKeyBuilder(caches.KeyConfig{Namespace: "staging"})
+	assert.Equal(t, "wx:staging:app-id:ak", b.BuildKey("app-id", caches.BizAccessToken))
+
+	b = caches.NewKeyBuilder(caches.KeyConfig{Namespace: "staging", MaxLength: 32})
+	assert.Equal(t, "wx:staging:app-id:ak", b.BuildKey("app-id", caches.BizAccessToken))
+	key := b.BuildKey("app-id", caches.BizComponentVerifyTicket)
+	assert.True(t, strings.HasPrefix(key, "wx:staging:"))
+	assert.Len(t, key, len("wx:staging:")+64)
+	assert.Equal(t, key, b.BuildKey("app-id", caches.BizComponentVerifyTicket))
+	assert.NotEqual(t, key, b.BuildKey("app-id", caches.BizComponentAccessToken))
+}
+
+func TestKeyBuilderNamespaceIsolation(t *testing.T) {
+	dir := t.TempDir()
+	staging := caches.NewFileCache(dir, caches.NewKeyBuilder(caches.KeyConfig{Namespace: "staging"}))
+	production := caches.NewFileCache(dir, caches.NewKeyBuilder(caches.KeyConfig{Namespace: "production"}))
+
+	err := staging.Set("app-id", caches.BizAccessToken, []byte("staging"), 10)
+	assert.NoError(t, err)
+	_, err = production.Get("app-id", caches.BizAccessToken)
+	assert.ErrorIs(t, err, caches.ErrKeyNotFound)
+
+	bizs := []string{
+		caches.BizAccessToken,
+		caches.BizAccessTokenLock,
+		caches.BizJSTicket,
+		caches.BizCardTicket,
+		caches.BizComponentVerifyTicket,
+		caches.BizComponentAccessToken,
+	}
+	keys := map[string]bool{}
+	for _, biz := range bizs {
+		keys[caches.DefaultKeyBuilder.BuildKey("app-id", biz)] = true
+	}
+	assert.Len(t, keys, len(bizs))
+}
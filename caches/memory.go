@@ -0,0 +1,196 @@
+package caches
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const DefaultCleanupInterval = time.Minute
+
+type MemoryCacheConfig struct {
+	MaxEntries      int           // Max number of items, the least recently used item is evicted when exceeded, unlimited if not given
+	CleanupInterval time.Duration // Interval to evict expired items in background, default value is 1 minute, disabled if negative
+	KeyBuilder      KeyBuilder    // Builds the key of items, default value is `DefaultKeyBuilder`
+}
+
+type cacheItem struct {
+	Key       string
+	Value     []byte
+	ExpiresAt time.Time
+}
+
+func (i *cacheItem) expired(now time.Time) bool {
+	return !i.ExpiresAt.After(now)
+}
+
+// MemoryCache is a thread-safe `Cache` stored in memory
+type MemoryCache struct {
+	mu         sync.Mutex
+	items      map[string]*list.Element
+	lru        *list.List
+	keys       KeyBuilder
+	maxEntries int
+	stop       chan struct{}
+	stopOnce   sync.Once
+}
+
+// NewMemoryCache creates a `MemoryCache`,
+// `Close` should be called to stop the cleanup goroutine when it is no longer used.
+func NewMemoryCache(conf MemoryCacheConfig) *MemoryCache {
+	if conf.CleanupInterval == 0 {
+		conf.CleanupInterval = DefaultCleanupInterval
+	}
+	if conf.KeyBuilder == nil {
+		conf.KeyBuilder = DefaultKeyBuilder
+	}
+
+	c := &MemoryCache{
+		items:      make(map[string]*list.Element),
+		lru:        list.New(),
+		keys:       conf.KeyBuilder,
+		maxEntries: conf.MaxEntries,
+		stop:       make(chan struct{}),
+	}
+	if conf.CleanupInterval > 0 {
+		go c.cleanup(conf.CleanupInterval)
+	}
+	return c
+}
+
+// NewDummyCache creates an unbounded `MemoryCache` without cleaning up in background,
+// expired items are evicted when they are read.
+func NewDummyCache() Cache {
+	return NewMemoryCache(MemoryCacheConfig{CleanupInterval: -1})
+}
+
+func (c *MemoryCache) Get(appId string, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item := c.get(c.keys.BuildKey(appId, key))
+	if item == nil {
+		return nil, ErrKeyNotFound
+	}
+	return item.Value, nil
+}
+
+func (c *MemoryCache) Set(appId string, key string, value []byte, expiresIn int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(c.keys.BuildKey(appId, key), value, expiresIn)
+	return nil
+}
+
+func (c *MemoryCache) Add(appId string, key string, value []byte, expiresIn int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fullKey := c.keys.BuildKey(appId, key)
+	if c.get(fullKey) != nil {
+		return ErrKeyExisted
+	}
+	c.set(fullKey, value, expiresIn)
+	return nil
+}
+
+func (c *MemoryCache) Delete(appId string, key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fullKey := c.keys.BuildKey(appId, key)
+	item := c.get(fullKey)
+	if item == nil {
+		return nil
+	}
+	if value != nil && string(item.Value) != string(value) {
+		return ErrValueNotMatched
+	}
+	c.remove(c.items[fullKey])
+	return nil
+}
+
+// Len returns the number of items in cache, including the expired ones not evicted yet
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Close stops the cleanup goroutine
+func (c *MemoryCache) Close() error {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+	return nil
+}
+
+// Returns the alive item and marks it as recently used, the caller must hold the lock
+func (c *MemoryCache) get(fullKey string) *cacheItem {
+	elem, ok := c.items[fullKey]
+	if !ok {
+		return nil
+	}
+	item := elem.Value.(*cacheItem)
+	if item.expired(time.Now()) {
+		c.remove(elem)
+		return nil
+	}
+	c.lru.MoveToFront(elem)
+	return item
+}
+
+// The caller must hold the lock
+func (c *MemoryCache) set(fullKey string, value []byte, expiresIn int) {
+	item := &cacheItem{
+		Key:       fullKey,
+		Value:     value,
+		ExpiresAt: time.Now().Add(time.Duration(expiresIn) * time.Second),
+	}
+	if elem, ok := c.items[fullKey]; ok {
+		elem.Value = item
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.items[fullKey] = c.lru.PushFront(item)
+	if c.maxEntries > 0 {
+		for c.lru.Len() > c.maxEntries {
+			c.remove(c.lru.Back())
+		}
+	}
+}
+
+// The caller must hold the lock
+func (c *MemoryCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.items, elem.Value.(*cacheItem).Key)
+}
+
+func (c *MemoryCache) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.deleteExpired()
+		}
+	}
+}
+
+func (c *MemoryCache) deleteExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for elem := c.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if elem.Value.(*cacheItem).expired(now) {
+			c.remove(elem)
+		}
+		elem = prev
+	}
+}
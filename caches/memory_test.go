@@ -0,0 +1,95 @@
+package caches_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache(t *testing.T) {
+	testCache(t, func() caches.Cache {
+		c := caches.NewMemoryCache(caches.MemoryCacheConfig{})
+		t.Cleanup(func() { c.Close() })
+		return c
+	})
+}
+
+func TestMemoryCacheMaxEntries(t *testing.T) {
+	cache := caches.NewMemoryCache(caches.MemoryCacheConfig{MaxEntries: 2})
+	defer cache.Close()
+
+	appId := "myAppId"
+	cache.Set(appId, "a", []byte("a"), 10)
+	cache.Set(appId, "b", []byte("b"), 10)
+
+	// a is recently used
+	_, err := cache.Get(appId, "a")
+	assert.NoError(t, err)
+
+	cache.Set(appId, "c", []byte("c"), 10)
+	assert.Equal(t, 2, cache.Len())
+
+	_, err = cache.Get(appId, "b")
+	assert.ErrorIs(t, err, caches.ErrKeyNotFound)
+	got, err := cache.Get(appId, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a"), got)
+	got, err = cache.Get(appId, "c")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("c"), got)
+
+	// overwrite does not evict
+	cache.Set(appId, "a", []byte("a2"), 10)
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestMemoryCacheCleanup(t *testing.T) {
+	cache := caches.NewMemoryCache(caches.MemoryCacheConfig{
+		CleanupInterval: 100 * time.Millisecond,
+	})
+	defer cache.Close()
+
+	cache.Set("myAppId", "short", []byte("value"), 1)
+	cache.Set("myAppId", "long", []byte("value"), 10)
+	assert.Equal(t, 2, cache.Len())
+
+	time.Sleep(1200 * time.Millisecond)
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestMemoryCacheConcurrency(t *testing.T) {
+	cache := caches.NewMemoryCache(caches.MemoryCacheConfig{
+		MaxEntries:      10,
+		CleanupInterval: 10 * time.Millisecond,
+	})
+	defer cache.Close()
+
+	var added sync.Map
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("key%d", i%5)
+			value := []byte(fmt.Sprint(i))
+			if cache.Add("myAppId", "lock", value, 10) == nil {
+				added.Store(i, true)
+			}
+			cache.Set("myAppId", key, value, 1)
+			cache.Get("myAppId", key)
+			cache.Delete("myAppId", key, value)
+		}(i)
+	}
+	wg.Wait()
+
+	count := 0
+	added.Range(func(key, value interface{}) bool {
+		count++
+		return true
+	})
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,100 @@
+package caches
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Delete the key only if its value matches, a missing key is treated as deleted
+const compareAndDeleteScript = `
+local value = redis.call("GET", KEYS[1])
+if not value then
+	return 1
+end
+if value == ARGV[1] then
+	redis.call("DEL", KEYS[1])
+	return 1
+end
+return 0
+`
+
+// RedisClient is the subset of redis commands used by the redis cache,
+// `*redis.Client`, `*redis.ClusterClient` and `*redis.Ring` all satisfy it.
+type RedisClient interface {
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd
+}
+
+type redisCache struct {
+	client RedisClient
+	keys   KeyBuilder
+}
+
+// NewRedisCache creates a `Cache` stored in redis,
+// `DefaultKeyBuilder` is used if no `KeyBuilder` is given.
+func NewRedisCache(client RedisClient, keys KeyBuilder) Cache {
+	if keys == nil {
+		keys = DefaultKeyBuilder
+	}
+	return &redisCache{
+		client: client,
+		keys:   keys,
+	}
+}
+
+func (c *redisCache) Get(appId string, key string) ([]byte, error) {
+	value, err := c.client.Get(context.Background(), c.keys.BuildKey(appId, key)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (c *redisCache) Set(appId string, key string, value []byte, expiresIn int) error {
+	return c.client.Set(
+		context.Background(),
+		c.keys.BuildKey(appId, key),
+		value,
+		time.Duration(expiresIn)*time.Second,
+	).Err()
+}
+
+func (c *redisCache) Add(appId string, key string, value []byte, expiresIn int) error {
+	ok, err := c.client.SetNX(
+		context.Background(),
+		c.keys.BuildKey(appId, key),
+		value,
+		time.Duration(expiresIn)*time.Second,
+	).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrKeyExisted
+	}
+	return nil
+}
+
+func (c *redisCache) Delete(appId string, key string, value []byte) error {
+	ctx := context.Background()
+	fullKey := c.keys.BuildKey(appId, key)
+	if value == nil {
+		return c.client.Del(ctx, fullKey).Err()
+	}
+
+	deleted, err := c.client.Eval(ctx, compareAndDeleteScript, []string{fullKey}, value).Int()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrValueNotMatched
+	}
+	return nil
+}
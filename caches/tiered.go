@@ -0,0 +1,78 @@
+package caches
+
+const DefaultLocalExpiresIn = 30
+
+// Invalidator is implemented by caches keeping local copies of values,
+// which should be dropped once the value is known to be outdated.
+type Invalidator interface {
+	// Invalidate drops the local copy of the value
+	Invalidate(appId string, key string)
+}
+
+// TieredCache serves reads from a local cache (L1) and writes through to a shared cache (L2)
+type TieredCache struct {
+	l1             Cache
+	l2             Cache
+	localExpiresIn int
+}
+
+// NewTiered creates a `TieredCache`,
+// values read from `l2` are kept in `l1` for `localExpiresIn` seconds at most,
+// default value is 30 seconds.
+// A reader waiting for the changes made by other processes should `Invalidate` the value before reading it.
+func NewTiered(l1 Cache, l2 Cache, localExpiresIn int) *TieredCache {
+	if localExpiresIn <= 0 {
+		localExpiresIn = DefaultLocalExpiresIn
+	}
+	return &TieredCache{
+		l1:             l1,
+		l2:             l2,
+		localExpiresIn: localExpiresIn,
+	}
+}
+
+func (c *TieredCache) Get(appId string, key string) ([]byte, error) {
+	value, err := c.l1.Get(appId, key)
+	if err == nil {
+		return value, nil
+	}
+
+	value, err = c.l2.Get(appId, key)
+	if err != nil {
+		return nil, err
+	}
+	c.l1.Set(appId, key, value, c.localExpiresIn)
+	return value, nil
+}
+
+func (c *TieredCache) Set(appId string, key string, value []byte, expiresIn int) error {
+	if err := c.l2.Set(appId, key, value, expiresIn); err != nil {
+		c.Invalidate(appId, key)
+		return err
+	}
+	return c.l1.Set(appId, key, value, c.getLocalExpiresIn(expiresIn))
+}
+
+func (c *TieredCache) Add(appId string, key string, value []byte, expiresIn int) error {
+	if err := c.l2.Add(appId, key, value, expiresIn); err != nil {
+		return err
+	}
+	return c.l1.Set(appId, key, value, c.getLocalExpiresIn(expiresIn))
+}
+
+func (c *TieredCache) Delete(appId string, key string, value []byte) error {
+	// The local copy may be outdated whether or not the value matches
+	defer c.Invalidate(appId, key)
+	return c.l2.Delete(appId, key, value)
+}
+
+func (c *TieredCache) Invalidate(appId string, key string) {
+	c.l1.Delete(appId, key, nil)
+}
+
+func (c *TieredCache) getLocalExpiresIn(expiresIn int) int {
+	if expiresIn < c.localExpiresIn {
+		return expiresIn
+	}
+	return c.localExpiresIn
+}
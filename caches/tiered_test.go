@@ -0,0 +1,59 @@
+package caches_test
+
+import (
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTieredCache(t *testing.T) {
+	testCache(t, func() caches.Cache {
+		return caches.NewTiered(caches.NewDummyCache(), caches.NewDummyCache(), 0)
+	})
+}
+
+func TestTieredCacheLocalCopy(t *testing.T) {
+	appId := "myAppId"
+	key := "myBiz"
+	l1 := caches.NewDummyCache()
+	l2 := caches.NewDummyCache()
+	cache := caches.NewTiered(l1, l2, 10)
+
+	// read through
+	l2.Set(appId, key, []byte("value"), 100)
+	got, err := cache.Get(appId, key)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), got)
+	got, err = l1.Get(appId, key)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), got)
+
+	// served from local copy
+	l2.Set(appId, key, []byte("value2"), 100)
+	got, err = cache.Get(appId, key)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), got)
+
+	// invalidate
+	cache.Invalidate(appId, key)
+	got, err = cache.Get(appId, key)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value2"), got)
+
+	// write through
+	err = cache.Set(appId, key, []byte("value3"), 100)
+	assert.NoError(t, err)
+	got, _ = l1.Get(appId, key)
+	assert.Equal(t, []byte("value3"), got)
+	got, _ = l2.Get(appId, key)
+	assert.Equal(t, []byte("value3"), got)
+
+	// unmatched delete still drops the local copy
+	err = cache.Delete(appId, key, []byte("other"))
+	assert.ErrorIs(t, err, caches.ErrValueNotMatched)
+	_, err = l1.Get(appId, key)
+	assert.ErrorIs(t, err, caches.ErrKeyNotFound)
+	got, _ = l2.Get(appId, key)
+	assert.Equal(t, []byte("value3"), got)
+}
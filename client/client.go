@@ -5,12 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
-	"strings"
+	"time"
 
 	"github.com/Xavier-Lam/go-wechat"
 	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/Xavier-Lam/go-wechat/internal/singleflight"
 )
 
 type HttpClient interface {
@@ -18,46 +20,51 @@ type HttpClient interface {
 }
 
 type Config struct {
-	AccessTokenClient AccessTokenClient // The client used for request access token
-	BaseApiUri        *url.URL          // The endpoint to request an API, if full path is not given, default value is 'https://api.weixin.qq.com'
-	Cache             caches.Cache      // Cache instance for managing tokens
-	HttpClient        HttpClient        // Default Http client to send request
+	AccessTokenClient AccessTokenClient  // The client used for request access token
+	BaseApiUri        *url.URL           // The endpoint to request an API, if full path is not given, default value is 'https://api.weixin.qq.com'
+	Cache             caches.Cache       // Cache instance for managing tokens
+	HttpClient        HttpClient         // Default Http client to send request
+	TokenLock         *TokenLockConfig   // Lock in `Cache` before fetching an access token, disabled if not given
+	Interceptors      []Interceptor      // Interceptors of every attempt to send a request, the first one is the outermost
+	RetryPolicy       *RetryPolicy       // Policy to retry failed requests, requests are only retried after refreshing a rejected token if not given
+	RateLimiter       *RateLimiterConfig // Client-side rate limiter of every attempt to send a request, disabled if not given
+	Observer          Observer           // Receives traces and metrics, default value is `NoopObserver`
+	Logger            *slog.Logger       // Logs requests and retries with credentials redacted, disabled if not given
+	TokenFetchTimeout time.Duration      // Max time to fetch an access token shared by concurrent callers, default value is 30s
 }
 
 const (
-	DefaultBaseApiUri = "https://api.weixin.qq.com"
-
-	ErrCodeInvalidCredential  = 40001
-	ErrCodeInvalidAccessToken = 40014
-	ErrCodeAccessTokenExpired = 42001
+	DefaultBaseApiUri        = "https://api.weixin.qq.com"
+	DefaultTokenFetchTimeout = 30 * time.Second
 )
 
-// Represents an error that occurs when the WeChat API returns an unexpected code.
-type WeChatApiError struct {
-	ErrCode int    `json:"errcode"`
-	ErrMsg  string `json:"errmsg"`
-	// Error happened while retrying
-	RetryError error
-}
-
-func (e WeChatApiError) Error() string {
-	if e.RetryError != nil {
-		return fmt.Sprintf("WeChat API error [%d]: %s (Retry error: %s)", e.ErrCode, e.ErrMsg, e.RetryError.Error())
-	} else {
-		return fmt.Sprintf("WeChat API error [%d]: %s", e.ErrCode, e.ErrMsg)
-	}
-}
-
 type WeChatClient interface {
 	// Sends a GET request
 	Get(url string, withCredential bool) (*http.Response, error)
 
+	// Sends a GET request with the given context
+	GetContext(ctx context.Context, url string, withCredential bool) (*http.Response, error)
+
 	// Sends a POST request with JSON data
 	PostJson(url string, data interface{}, withCredential bool) (*http.Response, error)
 
+	// Sends a POST request with JSON data and the given context
+	PostJsonContext(ctx context.Context, url string, data interface{}, withCredential bool) (*http.Response, error)
+
+	// Sends a POST request with multipart/form-data, the file is streamed as the `media` field
+	// along with the extra fields. The file must implement `io.Seeker` to be sent again on retry.
+	PostMultipart(url string, file *MultipartFile, fields map[string]string, withCredential bool) (*http.Response, error)
+
+	// Sends a POST request with multipart/form-data and the given context, see `PostMultipart`
+	PostMultipartContext(ctx context.Context, url string, file *MultipartFile, fields map[string]string, withCredential bool) (*http.Response, error)
+
 	// Sends a request with or without access_token based on `withCredential` flag
+	// The context of the request is used for fetching token and retrying.
 	Do(req *http.Request, withCredential bool) (*http.Response, error)
 
+	// Sends a request with the given context, see `Do`
+	DoContext(ctx context.Context, req *http.Request, withCredential bool) (*http.Response, error)
+
 	// Returns the WeChat Auth of the client
 	GetAuth() wechat.Auth
 
@@ -65,9 +72,17 @@ type WeChatClient interface {
 	// It may return an error along with the token if there is no `Cache` set up.
 	GetAccessToken() (*Token, error)
 
+	// GetAccessTokenContext retrieves the access token with the given context.
+	// It may return an error along with the token if there is no `Cache` set up.
+	GetAccessTokenContext(ctx context.Context) (*Token, error)
+
 	// FetchAccessToken renews and retrieves an access token.
 	// It may return an error along with the token if there is no `Cache` set up.
 	FetchAccessToken() (*Token, error)
+
+	// FetchAccessTokenContext renews and retrieves an access token with the given context.
+	// It may return an error along with the token if there is no `Cache` set up.
+	FetchAccessTokenContext(ctx context.Context) (*Token, error)
 }
 
 type weChatClient struct {
@@ -76,6 +91,12 @@ type weChatClient struct {
 	baseUri *url.URL
 	cache   caches.Cache
 	http    HttpClient
+	group   singleflight.Group
+	lock    *tokenLock
+	invoker Invoker
+	retry   *RetryPolicy
+	obs     Observer
+	logger  *slog.Logger
 }
 
 // Create a new `WeChatClient`
@@ -94,17 +115,47 @@ func New(auth wechat.Auth, conf Config) WeChatClient {
 		)
 	}
 
-	return &weChatClient{
+	var lock *tokenLock
+	if conf.TokenLock != nil && conf.Cache != nil {
+		lock = newTokenLock(conf.Cache, auth.GetAppId(), *conf.TokenLock)
+	}
+
+	if conf.Observer == nil {
+		conf.Observer = NoopObserver
+	}
+	if conf.TokenFetchTimeout <= 0 {
+		conf.TokenFetchTimeout = DefaultTokenFetchTimeout
+	}
+
+	c := &weChatClient{
 		akc:     conf.AccessTokenClient,
 		auth:    auth,
 		baseUri: conf.BaseApiUri,
 		cache:   conf.Cache,
 		http:    conf.HttpClient,
+		lock:    lock,
+		obs:     conf.Observer,
+		logger:  conf.Logger,
 	}
+	c.group.Timeout = conf.TokenFetchTimeout
+	if conf.RetryPolicy != nil {
+		c.retry = newRetryPolicy(*conf.RetryPolicy)
+	}
+	interceptors := conf.Interceptors
+	if conf.RateLimiter != nil {
+		limiter := newRateLimiter(auth.GetAppId(), *conf.RateLimiter)
+		interceptors = append(interceptors[:len(interceptors):len(interceptors)], limiter.intercept)
+	}
+	c.invoker = chainInterceptors(interceptors, c.send)
+	return c
 }
 
 func (c *weChatClient) Get(url string, withCredential bool) (*http.Response, error) {
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	return c.GetContext(context.Background(), url, withCredential)
+}
+
+func (c *weChatClient) GetContext(ctx context.Context, url string, withCredential bool) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -113,12 +164,16 @@ func (c *weChatClient) Get(url string, withCredential bool) (*http.Response, err
 }
 
 func (c *weChatClient) PostJson(url string, data interface{}, withCredential bool) (*http.Response, error) {
+	return c.PostJsonContext(context.Background(), url, data, withCredential)
+}
+
+func (c *weChatClient) PostJsonContext(ctx context.Context, url string, data interface{}, withCredential bool) (*http.Response, error) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, err
 	}
@@ -128,6 +183,19 @@ func (c *weChatClient) PostJson(url string, data interface{}, withCredential boo
 	return c.Do(req, withCredential)
 }
 
+func (c *weChatClient) PostMultipart(url string, file *MultipartFile, fields map[string]string, withCredential bool) (*http.Response, error) {
+	return c.PostMultipartContext(context.Background(), url, file, fields, withCredential)
+}
+
+func (c *weChatClient) PostMultipartContext(ctx context.Context, url string, file *MultipartFile, fields map[string]string, withCredential bool) (*http.Response, error) {
+	req, err := newMultipartRequest(ctx, url, file, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Do(req, withCredential)
+}
+
 func (c *weChatClient) Do(req *http.Request, withCredential bool) (*http.Response, error) {
 	ctx := req.Context()
 	ctx = context.WithValue(ctx, "token", nil)
@@ -138,7 +206,7 @@ func (c *weChatClient) Do(req *http.Request, withCredential bool) (*http.Respons
 	}
 
 	if withCredential {
-		token, err := c.GetAccessToken()
+		token, err := c.GetAccessTokenContext(ctx)
 		if token == nil {
 			return nil, err
 		}
@@ -157,27 +225,82 @@ func (c *weChatClient) Do(req *http.Request, withCredential bool) (*http.Respons
 	return c.handleError(err, req, resp)
 }
 
+func (c *weChatClient) DoContext(ctx context.Context, req *http.Request, withCredential bool) (*http.Response, error) {
+	return c.Do(req.WithContext(ctx), withCredential)
+}
+
 func (c *weChatClient) GetAuth() wechat.Auth {
 	return c.auth
 }
 
 func (c *weChatClient) GetAccessToken() (*Token, error) {
-	if c.cache != nil {
-		cachedValue, err := c.cache.Get(c.auth.GetAppId(), caches.BizAccessToken)
-		if err == nil {
-			token, err := deserializeToken(cachedValue)
-			if err == nil {
-				return token, nil
-			}
-		}
+	return c.GetAccessTokenContext(context.Background())
+}
+
+func (c *weChatClient) GetAccessTokenContext(ctx context.Context) (*Token, error) {
+	appIdAttr := Attribute{AttrAppId, c.auth.GetAppId()}
+	if token := c.cachedAccessToken(); token != nil {
+		c.obs.Count(ctx, MetricTokenCacheHit, 1, appIdAttr)
+		return token, nil
 	}
+	c.obs.Count(ctx, MetricTokenCacheMiss, 1, appIdAttr)
 
-	return c.FetchAccessToken()
+	return c.FetchAccessTokenContext(ctx)
+}
+
+// Reads the access token in cache without fetching, nil is returned if it is missing, expired
+// or there is no `Cache` set up. A local copy may outlive the shared value, so the expiry is checked again.
+func (c *weChatClient) cachedAccessToken() *Token {
+	if c.cache == nil {
+		return nil
+	}
+	cachedValue, err := c.cache.Get(c.auth.GetAppId(), caches.BizAccessToken)
+	if err != nil {
+		return nil
+	}
+	token, err := deserializeToken(cachedValue)
+	if err != nil {
+		return nil
+	}
+	if token.GetExpiresIn() <= 0 {
+		if invalidator, ok := c.cache.(caches.Invalidator); ok {
+			invalidator.Invalidate(c.auth.GetAppId(), caches.BizAccessToken)
+		}
+		return nil
+	}
+	return token
 }
 
 func (c *weChatClient) FetchAccessToken() (*Token, error) {
-	// TODO: prevent concurrent fetching
-	token, err := c.akc.GetAccessToken(c.auth)
+	return c.FetchAccessTokenContext(context.Background())
+}
+
+// Concurrent fetching is deduplicated, only one request is sent for an appId
+// at a time, the other callers wait for its result.
+// If `TokenLock` is configured, the deduplication spans processes sharing the `Cache`.
+// The shared fetching is not canceled by any single caller, it is bounded by `TokenFetchTimeout`
+// and each caller stops waiting once its own `ctx` is done.
+func (c *weChatClient) FetchAccessTokenContext(ctx context.Context) (*Token, error) {
+	rv, err := c.group.Do(ctx, c.auth.GetAppId(), func(ctx context.Context) (interface{}, error) {
+		if c.lock != nil {
+			return c.lock.Run(ctx, func() (*Token, error) {
+				return c.fetchAccessToken(ctx)
+			})
+		}
+		return c.fetchAccessToken(ctx)
+	})
+	token, _ := rv.(*Token)
+	return token, err
+}
+
+func (c *weChatClient) fetchAccessToken(ctx context.Context) (*Token, error) {
+	appIdAttr := Attribute{AttrAppId, c.auth.GetAppId()}
+	ctx, end := c.obs.StartSpan(ctx, SpanTokenFetch, appIdAttr)
+	start := time.Now()
+	token, err := requestAccessToken(ctx, c.akc, c.auth)
+	c.logTokenFetch(ctx, time.Since(start), err)
+	end(err)
+	c.obs.Count(ctx, MetricTokenFetch, 1, appIdAttr, resultAttribute(err))
 	if err != nil {
 		return nil, err
 	}
@@ -201,65 +324,175 @@ func (c *weChatClient) FetchAccessToken() (*Token, error) {
 }
 
 func (c *weChatClient) do(req *http.Request) (*http.Response, error) {
-	token := req.Context().Value("token")
-	if token != nil {
-		query := req.URL.Query()
-		query.Set("access_token", token.(*Token).GetAccessToken())
-		req.URL.RawQuery = query.Encode()
+	return c.invoker(req)
+}
+
+func (c *weChatClient) send(req *http.Request) (*http.Response, error) {
+	attrs := []Attribute{
+		{AttrAppId, c.auth.GetAppId()},
+		{AttrMethod, req.Method},
+		{AttrPath, req.URL.Path},
 	}
+	ctx, end := c.obs.StartSpan(req.Context(), SpanHttpAttempt, attrs...)
+	start := time.Now()
 
-	resp, err := c.http.Do(req)
+	resp, err := c.http.Do(withAccessToken(req.WithContext(ctx)))
 	if err != nil {
-		return nil, fmt.Errorf("sending request failed: %w", err)
+		err = sendingError(err)
+	} else {
+		attrs = append(attrs, Attribute{AttrStatusCode, resp.StatusCode})
+		err = processResponse(resp)
 	}
 
-	err = processResponse(resp)
+	duration := time.Since(start)
+	c.logAttempt(ctx, req, resp, err, duration)
+	c.obs.RecordDuration(ctx, MetricHttpDuration, duration, attrs...)
+	c.obs.Count(ctx, MetricApiResult, 1, append(attrs, resultAttribute(err))...)
+	end(err)
+
 	return resp, err
 }
 
+// Injects the access token of the request context into a copy of the URL,
+// the URL of the original request is shared by all attempts and kept untouched.
+func withAccessToken(req *http.Request) *http.Request {
+	token, _ := req.Context().Value("token").(*Token)
+	if token == nil {
+		return req
+	}
+
+	uri := *req.URL
+	query := uri.Query()
+	query.Set("access_token", token.GetAccessToken())
+	uri.RawQuery = query.Encode()
+	req.URL = &uri
+	return req
+}
+
+// Retries the request after refreshing the rejected access token,
+// or as the `RetryPolicy` allows. Errors of all attempts are chained by `RetryError`.
 func (c *weChatClient) handleError(err error, req *http.Request, resp *http.Response) (*http.Response, error) {
-	defer resp.Body.Close()
+	errs := []error{err}
+	tokenRefreshed := false
+	for attempt := 1; ; attempt++ {
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		ctx := req.Context()
+		if !tokenRefreshed && isTokenError(err) && ctx.Value("token") != nil {
+			tokenRefreshed = true
+			token, refreshErr := c.refreshAccessToken(ctx, err.(WeChatApiError))
+			if token == nil {
+				errs = append(errs, refreshErr)
+				break
+			}
+			req = req.WithContext(context.WithValue(ctx, "token", token))
+			c.observeRetry(ctx, req, RetryReasonToken, attempt, err)
+		} else if c.retry != nil && c.retry.shouldRetry(attempt, req, err) {
+			if waitErr := c.retry.wait(ctx, attempt); waitErr != nil {
+				errs = append(errs, waitErr)
+				break
+			}
+			c.observeRetry(ctx, req, RetryReasonPolicy, attempt, err)
+		} else {
+			break
+		}
 
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				errs = append(errs, err)
+				break
+			}
+			req.Body = body
+		} else if req.Body != nil && req.Body != http.NoBody {
+			errs = append(errs, ErrBodyNotReplayable)
+			break
+		}
+
+		resp, err = c.do(req)
+		if err == nil {
+			return resp, nil
+		}
+		errs = append(errs, err)
+	}
+
+	return nil, chainRetryErrors(errs)
+}
+
+func (c *weChatClient) observeRetry(ctx context.Context, req *http.Request, reason string, attempt int, cause error) {
+	c.logRetry(ctx, req, reason, attempt, cause)
+	c.obs.Count(
+		ctx,
+		MetricRetry,
+		1,
+		Attribute{AttrAppId, c.auth.GetAppId()},
+		Attribute{AttrPath, req.URL.Path},
+		Attribute{AttrRetryReason, reason},
+	)
+}
+
+// Renews the rejected access token of the request context.
+// The token in cache is reused if it is not the rejected one, as it has been renewed by another request,
+// so that a token just obtained is never invalidated by a force refresh.
+func (c *weChatClient) refreshAccessToken(ctx context.Context, apiError WeChatApiError) (*Token, error) {
+	// Do not let a local copy of the rejected token survive the refresh
+	if invalidator, ok := c.cache.(caches.Invalidator); ok {
+		invalidator.Invalidate(c.auth.GetAppId(), caches.BizAccessToken)
+	}
+
+	rejected, _ := ctx.Value("token").(*Token)
+	if rejected != nil {
+		if token := c.cachedAccessToken(); token != nil && token.GetAccessToken() != rejected.GetAccessToken() {
+			return token, nil
+		}
+		ctx = withRejectedToken(ctx, rejected)
+	}
+
+	if apiError.ErrCode != ErrCodeAccessTokenExpired {
+		ctx = withForceRefresh(ctx)
+	}
+	return c.FetchAccessTokenContext(ctx)
+}
+
+func isTokenError(err error) bool {
 	apiError, ok := err.(WeChatApiError)
 	if !ok {
-		return nil, err
+		return false
 	}
-
 	switch apiError.ErrCode {
 	case ErrCodeAccessTokenExpired,
 		ErrCodeInvalidAccessToken,
 		ErrCodeInvalidCredential:
-		ctx := req.Context()
-		if ctx.Value("token") != nil {
-			var token *Token
-			token, apiError.RetryError = c.FetchAccessToken()
-			if token == nil {
-				return nil, apiError
-			}
-
-			ctx = context.WithValue(ctx, "token", token)
-			req = req.WithContext(ctx)
-			return c.do(req)
-		}
+		return true
 	}
-
-	return nil, apiError
+	return false
 }
 
 func processResponse(resp *http.Response) error {
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("HTTP request failed with status code: %d", resp.StatusCode)
+		return HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+		}
 	}
 
-	ct := resp.Header.Get("Content-Type")
-	if strings.HasPrefix(ct, "application/json") {
-		var apiError WeChatApiError
-		err := GetJson(resp, &apiError)
-		if err != nil {
-			return fmt.Errorf("failed to decode JSON: %w", err)
-		} else if apiError.ErrCode != 0 {
-			return apiError
+	// Some endpoints report errors as JSON with a text/plain content type
+	if !sniffJSON(resp) {
+		return nil
+	}
+
+	var apiError WeChatApiError
+	err := GetJson(resp, &apiError)
+	if err != nil {
+		if isJSONContentType(resp) {
+			return MalformedResponseError{Err: err}
 		}
+		return nil
+	} else if apiError.ErrCode != 0 {
+		apiError.Rid = parseRid(apiError.ErrMsg)
+		return apiError
 	}
 
 	return nil
@@ -2,11 +2,17 @@ package client_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/Xavier-Lam/go-wechat"
 	"github.com/Xavier-Lam/go-wechat/caches"
@@ -342,3 +348,254 @@ func TestWeChatClientGetAppId(t *testing.T) {
 	assert.Equal(t, appID, result.GetAppId())
 	assert.Equal(t, appSecret, result.GetAppSecret())
 }
+
+func TestWeChatClientContext(t *testing.T) {
+	type ctxKey struct{}
+	accessToken := "token"
+	expectedUrl := "https://api.weixin.qq.com/some-endpoint"
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	send := map[string]func(c client.WeChatClient) (*http.Response, error){
+		"GET": func(c client.WeChatClient) (*http.Response, error) {
+			return c.GetContext(ctx, expectedUrl, true)
+		},
+		"POST": func(c client.WeChatClient) (*http.Response, error) {
+			return c.PostJsonContext(ctx, expectedUrl, map[string]string{}, true)
+		},
+		"PUT": func(c client.WeChatClient) (*http.Response, error) {
+			req, _ := http.NewRequest("PUT", expectedUrl, nil)
+			return c.DoContext(ctx, req, true)
+		},
+	}
+
+	for method, f := range send {
+		mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+			assert.Equal(t, "value", req.Context().Value(ctxKey{}))
+			if calls == 1 {
+				test.AssertEndpointEqual(t, client.DefaultAccessTokenUri, req.URL)
+				return test.Responses.Json(`{"access_token": "invalid", "expires_in": 7200}`)
+			} else if calls == 2 {
+				assert.Equal(t, method, req.Method)
+				assert.Equal(t, "invalid", req.URL.Query().Get("access_token"))
+				return test.Responses.Json(`{"errcode": 40001, "errmsg": "invalid credential"}`)
+			} else if calls == 3 {
+				test.AssertEndpointEqual(t, client.DefaultAccessTokenUri, req.URL)
+				return test.Responses.Json(`{"access_token": "token", "expires_in": 7200}`)
+			} else if calls == 4 {
+				assert.Equal(t, method, req.Method)
+				assert.Equal(t, accessToken, req.URL.Query().Get("access_token"))
+				return test.Responses.Empty()
+			} else {
+				assert.Fail(t, "Unexpected calls")
+				return nil, nil
+			}
+		})
+
+		config := client.Config{
+			HttpClient: mc,
+			Cache:      caches.NewDummyCache(),
+		}
+		c := client.New(auth, config)
+
+		resp, err := f(c)
+		assert.NoError(t, err)
+		assert.Equal(t, emptyResponse, resp)
+	}
+}
+
+func TestWeChatClientContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		test.AssertEndpointEqual(t, client.DefaultAccessTokenUri, req.URL)
+		return nil, req.Context().Err()
+	})
+
+	config := client.Config{
+		HttpClient: mc,
+		Cache:      caches.NewDummyCache(),
+	}
+	c := client.New(auth, config)
+
+	_, err := c.GetContext(ctx, "/some-endpoint", true)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = c.FetchAccessTokenContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+type slowAccessTokenClient struct {
+	calls int32
+	delay time.Duration
+}
+
+func (c *slowAccessTokenClient) GetAccessToken(auth wechat.Auth) (*client.Token, error) {
+	return c.GetAccessTokenContext(context.Background(), auth)
+}
+
+func (c *slowAccessTokenClient) GetAccessTokenContext(ctx context.Context, auth wechat.Auth) (*client.Token, error) {
+	atomic.AddInt32(&c.calls, 1)
+	select {
+	case <-time.After(c.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return client.NewToken("token", client.DefaultTokenExpiresIn), nil
+}
+
+func TestWeChatClientFetchAccessTokenConcurrently(t *testing.T) {
+	akc := &slowAccessTokenClient{delay: 50 * time.Millisecond}
+	config := client.Config{
+		AccessTokenClient: akc,
+		Cache:             caches.NewDummyCache(),
+	}
+	c := client.New(auth, config)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := c.FetchAccessToken()
+			assert.NoError(t, err)
+			assert.Equal(t, "token", token.GetAccessToken())
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&akc.calls))
+
+	_, err := c.FetchAccessToken()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&akc.calls))
+}
+
+func TestWeChatClientFetchAccessTokenCallerDeadline(t *testing.T) {
+	akc := &slowAccessTokenClient{delay: 50 * time.Millisecond}
+	config := client.Config{
+		AccessTokenClient: akc,
+		Cache:             caches.NewDummyCache(),
+	}
+	c := client.New(auth, config)
+
+	// The fetching started by a caller with a short deadline is still shared by the others
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	go c.FetchAccessTokenContext(ctx)
+	time.Sleep(5 * time.Millisecond)
+
+	token, err := c.FetchAccessTokenContext(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token.GetAccessToken())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&akc.calls))
+}
+
+type failingAccessTokenClient struct{}
+
+func (c *failingAccessTokenClient) GetAccessToken(auth wechat.Auth) (*client.Token, error) {
+	return nil, errors.New("fetching failed")
+}
+
+func TestWeChatClientInvalidateLocalToken(t *testing.T) {
+	invalidToken := "invalid"
+	accessToken := "token"
+
+	expectedUrl := "https://api.weixin.qq.com/some-endpoint"
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		if calls == 1 {
+			assert.Equal(t, invalidToken, req.URL.Query().Get("access_token"))
+			return test.Responses.Json(`{"errcode": 40001, "errmsg": "invalid credential"}`)
+		}
+		assert.Equal(t, accessToken, req.URL.Query().Get("access_token"))
+		return test.Responses.Empty()
+	})
+
+	// The token in shared cache has been renewed by another process
+	l1 := caches.NewDummyCache()
+	l2 := caches.NewDummyCache()
+	serializedToken, _ := client.SerializeToken(client.NewToken(invalidToken, 3600))
+	l1.Set(appID, caches.BizAccessToken, serializedToken, 3600)
+	serializedToken, _ = client.SerializeToken(client.NewToken(accessToken, 3600))
+	l2.Set(appID, caches.BizAccessToken, serializedToken, 3600)
+
+	config := client.Config{
+		AccessTokenClient: &failingAccessTokenClient{},
+		HttpClient:        mc,
+		Cache:             caches.NewTiered(l1, l2, 0),
+	}
+	c := client.New(auth, config)
+
+	// The renewed token is reused without fetching
+	resp, err := c.Get(expectedUrl, true)
+	assert.NoError(t, err)
+	assert.Equal(t, emptyResponse, resp)
+}
+
+func TestWeChatClientExpiredLocalToken(t *testing.T) {
+	// The local copy outlives the token expired in shared cache
+	l1 := caches.NewDummyCache()
+	expired := fmt.Sprintf(
+		`{"access_token": "expired", "expires_in": 7200, "created_at": %q}`,
+		time.Now().Add(-3*time.Hour).Format(time.RFC3339),
+	)
+	l1.Set(appID, caches.BizAccessToken, []byte(expired), 30)
+
+	c := client.New(auth, client.Config{
+		AccessTokenClient: test.NewMockAccessTokenClient("token"),
+		Cache:             caches.NewTiered(l1, caches.NewDummyCache(), 0),
+	})
+
+	token, err := c.GetAccessToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token.GetAccessToken())
+}
+
+func TestWeChatClientReuseRenewedToken(t *testing.T) {
+	sent := make(chan struct{}, 2)
+	release := make(chan struct{})
+	mc := httpClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Query().Get("access_token") == "rejected" {
+			sent <- struct{}{}
+			<-release
+			return test.Responses.Json(`{"errcode": 40001, "errmsg": "invalid credential"}`)
+		}
+		return test.Responses.Empty()
+	})
+
+	// Both requests are sent with the token renewed afterwards
+	cache := caches.NewDummyCache()
+	serializedToken, _ := client.SerializeToken(client.NewToken("rejected", 3600))
+	cache.Set(appID, caches.BizAccessToken, serializedToken, 3600)
+	akc := &slowAccessTokenClient{}
+	c := client.New(auth, client.Config{
+		AccessTokenClient: akc,
+		HttpClient:        mc,
+		Cache:             cache,
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Get("https://api.weixin.qq.com/some-endpoint", true)
+			assert.NoError(t, err)
+		}()
+	}
+	// The second rejection arrives after the first refresh
+	<-sent
+	<-sent
+	release <- struct{}{}
+	time.Sleep(50 * time.Millisecond)
+	release <- struct{}{}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&akc.calls))
+}
+
+type httpClientFunc func(req *http.Request) (*http.Response, error)
+
+func (f httpClientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,74 @@
+//go:build ignore
+// +build ignore
+
+// Generates errcodes.go from errcodes.tsv
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var categories = map[string]string{
+	"auth":        "CategoryAuth",
+	"quota":       "CategoryQuota",
+	"invalid-arg": "CategoryInvalidArgument",
+	"user-state":  "CategoryUserState",
+	"rate-limit":  "CategoryRateLimit",
+	"system":      "CategorySystem",
+}
+
+func main() {
+	f, err := os.Open("errcodes.tsv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintln(buf, "// Code generated by errcodes_gen.go from errcodes.tsv; DO NOT EDIT.")
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "package client")
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "var errCodes = map[int]errCodeInfo{")
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.SplitN(text, "\t", 3)
+		if len(fields) != 3 {
+			log.Fatalf("line %d: expected 3 fields", line)
+		}
+		code, err := strconv.Atoi(fields[0])
+		if err != nil {
+			log.Fatalf("line %d: %v", line, err)
+		}
+		category, ok := categories[fields[1]]
+		if !ok {
+			log.Fatalf("line %d: unknown category %q", line, fields[1])
+		}
+		fmt.Fprintf(buf, "\t%d: {%s, %q},\n", code, category, fields[2])
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintln(buf, "}")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("errcodes.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
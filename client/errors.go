@@ -0,0 +1,168 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+//go:generate go run errcodes_gen.go
+
+const (
+	ErrCodeSystemBusy         = -1
+	ErrCodeInvalidCredential  = 40001
+	ErrCodeInvalidAccessToken = 40014
+	ErrCodeAccessTokenExpired = 42001
+	ErrCodeApiQuotaExceeded   = 45009
+	ErrCodeApiMinuteQuota     = 45011
+)
+
+// ErrorCategory classifies the errors returned by WeChat server
+type ErrorCategory int
+
+const (
+	CategoryUnknown         ErrorCategory = iota
+	CategoryAuth                          // Invalid or expired credentials, or unauthorized api
+	CategoryQuota                         // Daily quota or count limit exceeded
+	CategoryInvalidArgument               // Malformed or invalid request
+	CategoryUserState                     // The user is not in a state to accept the request
+	CategoryRateLimit                     // Calls are too frequent
+	CategorySystem                        // System error of WeChat server
+)
+
+func (c ErrorCategory) String() string {
+	switch c {
+	case CategoryAuth:
+		return "auth"
+	case CategoryQuota:
+		return "quota"
+	case CategoryInvalidArgument:
+		return "invalid-arg"
+	case CategoryUserState:
+		return "user-state"
+	case CategoryRateLimit:
+		return "rate-limit"
+	case CategorySystem:
+		return "system"
+	}
+	return "unknown"
+}
+
+// A sentinel error matching errors of a category via `errors.Is`
+type categoryError struct {
+	category ErrorCategory
+	msg      string
+}
+
+func (e *categoryError) Error() string {
+	return e.msg
+}
+
+var (
+	ErrAuth            error = &categoryError{CategoryAuth, "WeChat API auth error"}
+	ErrQuotaExceeded   error = &categoryError{CategoryQuota, "WeChat API quota exceeded"}
+	ErrInvalidArgument error = &categoryError{CategoryInvalidArgument, "WeChat API invalid argument"}
+	ErrUserState       error = &categoryError{CategoryUserState, "WeChat API user state error"}
+	ErrRateLimited     error = &categoryError{CategoryRateLimit, "WeChat API rate limited"}
+	ErrSystem          error = &categoryError{CategorySystem, "WeChat API system error"}
+)
+
+type errCodeInfo struct {
+	Category ErrorCategory
+	Message  string
+}
+
+// Represents an error that occurs when the WeChat API returns an unexpected code.
+type WeChatApiError struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+	// Request id attached to the errmsg, which can be looked up by `/cgi-bin/openapi/rid/get`
+	Rid string `json:"-"`
+	// Error happened while retrying
+	RetryError error
+}
+
+var ridPattern = regexp.MustCompile(`rid:\s*([\w-]+)`)
+
+// Extracts the request id from an errmsg like 'invalid credential rid: 5f1ab0a2-1c56e4d8-3a1b2c3d'
+func parseRid(errMsg string) string {
+	match := ridPattern.FindStringSubmatch(errMsg)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+func (e WeChatApiError) Error() string {
+	if e.RetryError != nil {
+		return fmt.Sprintf("WeChat API error [%d]: %s (Retry error: %s)", e.ErrCode, e.ErrMsg, e.RetryError.Error())
+	} else {
+		return fmt.Sprintf("WeChat API error [%d]: %s", e.ErrCode, e.ErrMsg)
+	}
+}
+
+// Unwrap returns the error happened while retrying, so that the errors of all attempts can be matched
+func (e WeChatApiError) Unwrap() error {
+	return e.RetryError
+}
+
+// Category returns the category of the error code, `CategoryUnknown` if it is not documented
+func (e WeChatApiError) Category() ErrorCategory {
+	return errCodes[e.ErrCode].Category
+}
+
+// Description returns the documented description of the error code
+func (e WeChatApiError) Description() string {
+	return errCodes[e.ErrCode].Message
+}
+
+// Is matches the category sentinel errors such as `ErrQuotaExceeded`,
+// or a `WeChatApiError` with the same code.
+func (e WeChatApiError) Is(target error) bool {
+	switch t := target.(type) {
+	case *categoryError:
+		return t.category == e.Category()
+	case WeChatApiError:
+		return t.ErrCode == e.ErrCode
+	}
+	return false
+}
+
+// HTTPStatusError is returned when WeChat server responds a non-2xx status
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e HTTPStatusError) Error() string {
+	return fmt.Sprintf("HTTP request failed with status code: %d", e.StatusCode)
+}
+
+// Category returns `CategoryRateLimit` for 429 and `CategorySystem` for 5xx
+func (e HTTPStatusError) Category() ErrorCategory {
+	if e.StatusCode == http.StatusTooManyRequests {
+		return CategoryRateLimit
+	} else if e.StatusCode >= 500 {
+		return CategorySystem
+	}
+	return CategoryUnknown
+}
+
+// Is matches the category sentinel errors
+func (e HTTPStatusError) Is(target error) bool {
+	t, ok := target.(*categoryError)
+	return ok && t.category == e.Category()
+}
+
+// MalformedResponseError is returned when the response cannot be decoded
+type MalformedResponseError struct {
+	Err error
+}
+
+func (e MalformedResponseError) Error() string {
+	return fmt.Sprintf("failed to decode JSON: %s", e.Err.Error())
+}
+
+func (e MalformedResponseError) Unwrap() error {
+	return e.Err
+}
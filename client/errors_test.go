@@ -0,0 +1,122 @@
+package client_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Xavier-Lam/go-wechat/client"
+	"github.com/Xavier-Lam/go-wechat/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeChatApiErrorCategory(t *testing.T) {
+	cases := map[int]error{
+		-1:                               client.ErrSystem,
+		client.ErrCodeInvalidCredential:  client.ErrAuth,
+		client.ErrCodeApiQuotaExceeded:   client.ErrQuotaExceeded,
+		client.ErrCodeApiMinuteQuota:     client.ErrRateLimited,
+		40003:                            client.ErrInvalidArgument,
+		43004:                            client.ErrUserState,
+		client.ErrCodeAccessTokenExpired: client.ErrAuth,
+	}
+	sentinels := []error{
+		client.ErrAuth,
+		client.ErrQuotaExceeded,
+		client.ErrInvalidArgument,
+		client.ErrUserState,
+		client.ErrRateLimited,
+		client.ErrSystem,
+	}
+
+	for code, expected := range cases {
+		err := error(client.WeChatApiError{ErrCode: code})
+		for _, sentinel := range sentinels {
+			assert.Equal(t, sentinel == expected, errors.Is(err, sentinel), "errcode %d, sentinel %s", code, sentinel)
+		}
+	}
+
+	err := client.WeChatApiError{ErrCode: 45009, ErrMsg: "api freq out of limit"}
+	assert.Equal(t, client.CategoryQuota, err.Category())
+	assert.Equal(t, "quota", err.Category().String())
+	assert.Equal(t, "api freq out of limit", err.Description())
+	assert.ErrorIs(t, err, client.WeChatApiError{ErrCode: 45009})
+	assert.NotErrorIs(t, err, client.WeChatApiError{ErrCode: 45011})
+
+	unknown := client.WeChatApiError{ErrCode: 12345}
+	assert.Equal(t, client.CategoryUnknown, unknown.Category())
+	for _, sentinel := range sentinels {
+		assert.NotErrorIs(t, unknown, sentinel)
+	}
+}
+
+func TestHTTPStatusError(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		recorder := httptest.NewRecorder()
+		recorder.WriteHeader(http.StatusServiceUnavailable)
+		return recorder.Result(), nil
+	})
+	c := client.New(auth, client.Config{HttpClient: mc})
+
+	_, err := c.Get("https://api.weixin.qq.com/some-endpoint", false)
+	var statusError client.HTTPStatusError
+	assert.ErrorAs(t, err, &statusError)
+	assert.Equal(t, http.StatusServiceUnavailable, statusError.StatusCode)
+	assert.ErrorIs(t, err, client.ErrSystem)
+
+	assert.ErrorIs(t, client.HTTPStatusError{StatusCode: 429}, client.ErrRateLimited)
+	assert.NotErrorIs(t, client.HTTPStatusError{StatusCode: 404}, client.ErrSystem)
+}
+
+func TestMalformedResponseError(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		return test.Responses.Json(`{"errcode": `)
+	})
+	c := client.New(auth, client.Config{HttpClient: mc})
+
+	_, err := c.Get("https://api.weixin.qq.com/some-endpoint", false)
+	var malformedError client.MalformedResponseError
+	assert.ErrorAs(t, err, &malformedError)
+	var syntaxError *json.SyntaxError
+	assert.ErrorAs(t, err, &syntaxError)
+}
+
+func TestWeChatApiErrorRid(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		return test.Responses.Json(`{"errcode": 45009, "errmsg": "reach max api daily quota limit rid: 617682e0-09059ac5-34a8e2ea"}`)
+	})
+	c := client.New(auth, client.Config{HttpClient: mc})
+
+	_, err := c.Get("https://api.weixin.qq.com/some-endpoint", false)
+	assert.Equal(t, "617682e0-09059ac5-34a8e2ea", err.(client.WeChatApiError).Rid)
+
+	mc = test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		return test.Responses.Json(`{"errcode": 45009, "errmsg": "reach max api daily quota limit"}`)
+	})
+	c = client.New(auth, client.Config{HttpClient: mc})
+
+	_, err = c.Get("https://api.weixin.qq.com/some-endpoint", false)
+	assert.Empty(t, err.(client.WeChatApiError).Rid)
+}
+
+func TestWeChatApiErrorRetried(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		if calls == 1 {
+			return test.Responses.Json(`{"errcode": -1, "errmsg": "system error"}`)
+		}
+		return test.Responses.Json(`{"errcode": 45009, "errmsg": "reach max api daily quota limit"}`)
+	})
+	policy := client.DefaultRetryPolicy
+	policy.InitialBackoff = time.Millisecond
+	c := client.New(auth, client.Config{HttpClient: mc, RetryPolicy: &policy})
+
+	// Both the first attempt and the retry are matched
+	_, err := c.Get("https://api.weixin.qq.com/some-endpoint", false)
+	assert.ErrorIs(t, err, client.ErrSystem)
+	assert.ErrorIs(t, err, client.ErrQuotaExceeded)
+	assert.ErrorIs(t, err, client.WeChatApiError{ErrCode: client.ErrCodeApiQuotaExceeded})
+	assert.Equal(t, client.ErrCodeSystemBusy, err.(client.WeChatApiError).ErrCode)
+}
@@ -1,6 +1,7 @@
 package client
 
 var (
-	SerializeToken   = serializeToken
-	DeserializeToken = deserializeToken
+	SerializeToken    = serializeToken
+	DeserializeToken  = deserializeToken
+	WithRejectedToken = withRejectedToken
 )
@@ -0,0 +1,25 @@
+package client
+
+import "net/http"
+
+// Invoker sends a request and processes its response.
+// The error returned is a `WeChatApiError` if WeChat server responds an error code.
+type Invoker func(req *http.Request) (*http.Response, error)
+
+// Interceptor intercepts every attempt to send a request.
+// The request it receives has not been injected with access_token yet,
+// and the error returned by `next` is the parsed `WeChatApiError` if there is any.
+// An interceptor can modify the request or the results, or short-circuit the chain
+// by returning without calling `next`.
+type Interceptor func(req *http.Request, next Invoker) (*http.Response, error)
+
+// Chains the interceptors, the first one is the outermost
+func chainInterceptors(interceptors []Interceptor, invoker Invoker) Invoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(req *http.Request) (*http.Response, error) {
+			return interceptor(req, next)
+		}
+	}
+	return invoker
+}
@@ -0,0 +1,105 @@
+package client_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/Xavier-Lam/go-wechat/client"
+	"github.com/Xavier-Lam/go-wechat/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterceptors(t *testing.T) {
+	expectedUrl := "https://api.weixin.qq.com/some-endpoint"
+	steps := []string{}
+
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		steps = append(steps, "send")
+		assert.Equal(t, "token", req.URL.Query().Get("access_token"))
+		assert.Equal(t, "value", req.Header.Get("X-Custom"))
+		return test.Responses.Json(`{"errcode": 45009, "errmsg": "api freq out of limit"}`)
+	})
+
+	outer := func(req *http.Request, next client.Invoker) (*http.Response, error) {
+		steps = append(steps, "outer")
+		resp, err := next(req)
+		steps = append(steps, "outer done")
+		return resp, err
+	}
+	inner := func(req *http.Request, next client.Invoker) (*http.Response, error) {
+		steps = append(steps, "inner")
+		assert.Empty(t, req.URL.Query().Get("access_token"))
+		req.Header.Set("X-Custom", "value")
+
+		resp, err := next(req)
+		assert.Equal(t, 45009, err.(client.WeChatApiError).ErrCode)
+		steps = append(steps, "inner done")
+		// swallow the error
+		return resp, nil
+	}
+
+	config := client.Config{
+		AccessTokenClient: test.NewMockAccessTokenClient("token"),
+		Cache:             caches.NewDummyCache(),
+		HttpClient:        mc,
+		Interceptors:      []client.Interceptor{outer, inner},
+	}
+	c := client.New(auth, config)
+
+	resp, err := c.Get(expectedUrl, true)
+	assert.NoError(t, err)
+	assert.NotNil(t, resp)
+	assert.Equal(t, []string{"outer", "inner", "send", "inner done", "outer done"}, steps)
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Fail(t, "Unexpected calls")
+		return nil, nil
+	})
+
+	interceptor := func(req *http.Request, next client.Invoker) (*http.Response, error) {
+		return nil, client.WeChatApiError{ErrCode: -1, ErrMsg: "system error"}
+	}
+
+	config := client.Config{
+		HttpClient:   mc,
+		Interceptors: []client.Interceptor{interceptor},
+	}
+	c := client.New(auth, config)
+
+	resp, err := c.Get("https://api.weixin.qq.com/some-endpoint", false)
+	assert.Nil(t, resp)
+	assert.Equal(t, -1, err.(client.WeChatApiError).ErrCode)
+}
+
+func TestInterceptorRetry(t *testing.T) {
+	attempts := 0
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, "token", req.URL.Query().Get("access_token"))
+		if calls == 1 {
+			return test.Responses.Json(`{"errcode": 40001, "errmsg": "invalid credential"}`)
+		}
+		return test.Responses.Empty()
+	})
+
+	interceptor := func(req *http.Request, next client.Invoker) (*http.Response, error) {
+		attempts++
+		// The token injected into the previous attempt is not seen
+		assert.False(t, req.URL.Query().Has("access_token"))
+		return next(req)
+	}
+
+	config := client.Config{
+		AccessTokenClient: test.NewMockAccessTokenClient("token"),
+		Cache:             caches.NewDummyCache(),
+		HttpClient:        mc,
+		Interceptors:      []client.Interceptor{interceptor},
+	}
+	c := client.New(auth, config)
+
+	_, err := c.Get("https://api.weixin.qq.com/some-endpoint", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+}
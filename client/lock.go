@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
+)
+
+const (
+	DefaultTokenLockTTL          = 10 * time.Second
+	DefaultTokenLockPollInterval = 100 * time.Millisecond
+)
+
+var ErrTokenLockTimeout = errors.New("timed out waiting for access token lock")
+
+// What to do when a process gave up waiting for the lock holder
+type TokenLockFallback int
+
+const (
+	// Fetch the token without holding the lock
+	TokenLockFallbackFetch TokenLockFallback = iota
+	// Return `ErrTokenLockTimeout`
+	TokenLockFallbackError
+)
+
+// TokenLockConfig configures the lock taken in `Cache` before fetching an access token,
+// so only one process fetches a token at a time when many of them share a `Cache`.
+// The processes losing the race poll the `Cache` for the token fetched by the winner.
+type TokenLockConfig struct {
+	TTL          time.Duration     // Max time a lock is held, the lock is released after it even if the holder dies, default value is 10s
+	WaitTimeout  time.Duration     // Max time to wait for the lock holder, default value is `TTL`
+	PollInterval time.Duration     // Interval to poll the `Cache`, default value is 100ms
+	Fallback     TokenLockFallback // What to do after `WaitTimeout`, default value is `TokenLockFallbackFetch`
+}
+
+type tokenLock struct {
+	cache  caches.Cache
+	appId  string
+	config TokenLockConfig
+}
+
+func newTokenLock(cache caches.Cache, appId string, conf TokenLockConfig) *tokenLock {
+	if conf.TTL <= 0 {
+		conf.TTL = DefaultTokenLockTTL
+	}
+	if conf.WaitTimeout <= 0 {
+		conf.WaitTimeout = conf.TTL
+	}
+	if conf.PollInterval <= 0 {
+		conf.PollInterval = DefaultTokenLockPollInterval
+	}
+	return &tokenLock{
+		cache:  cache,
+		appId:  appId,
+		config: conf,
+	}
+}
+
+// Run calls `fetch` while holding the lock, or waits for the lock holder to store a token
+// different from the rejected one, or from the one in cache before waiting if no token is rejected.
+func (l *tokenLock) Run(ctx context.Context, fetch func() (*Token, error)) (*Token, error) {
+	var stale string
+	if rejected := getRejectedToken(ctx); rejected != nil {
+		stale = rejected.GetAccessToken()
+	} else if cachedValue, err := getShared(l.cache, l.appId, caches.BizAccessToken); err == nil {
+		if token, err := deserializeToken(cachedValue); err == nil {
+			stale = token.GetAccessToken()
+		}
+	}
+
+	deadline := time.NewTimer(l.config.WaitTimeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(l.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		value, err := l.acquire()
+		if err == nil {
+			defer l.cache.Delete(l.appId, caches.BizAccessTokenLock, value)
+			// The previous holder may have stored a token right before releasing the lock
+			if token := l.renewed(stale); token != nil {
+				return token, nil
+			}
+			return fetch()
+		} else if err != caches.ErrKeyExisted {
+			// The lock is best-effort, do not fail the fetching for a faulty cache
+			return fetch()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			if l.config.Fallback == TokenLockFallbackError {
+				return nil, ErrTokenLockTimeout
+			}
+			return fetch()
+		case <-ticker.C:
+		}
+
+		if token := l.renewed(stale); token != nil {
+			return token, nil
+		}
+	}
+}
+
+// Returns the token in cache if it is different from `stale`
+func (l *tokenLock) renewed(stale string) *Token {
+	cachedValue, err := getShared(l.cache, l.appId, caches.BizAccessToken)
+	if err != nil {
+		return nil
+	}
+	token, err := deserializeToken(cachedValue)
+	if err != nil || token.GetAccessToken() == stale {
+		return nil
+	}
+	return token
+}
+
+func (l *tokenLock) acquire() ([]byte, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	value := []byte(hex.EncodeToString(b))
+	ttl := int((l.config.TTL + time.Second - 1) / time.Second)
+	return value, l.cache.Add(l.appId, caches.BizAccessTokenLock, value, ttl)
+}
@@ -0,0 +1,204 @@
+package client_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/Xavier-Lam/go-wechat/client"
+	"github.com/Xavier-Lam/go-wechat/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenLockAcquired(t *testing.T) {
+	cache := caches.NewDummyCache()
+	config := client.Config{
+		AccessTokenClient: test.NewMockAccessTokenClient("token"),
+		Cache:             cache,
+		TokenLock:         &client.TokenLockConfig{},
+	}
+	c := client.New(auth, config)
+
+	token, err := c.FetchAccessToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token.GetAccessToken())
+
+	// lock released
+	_, err = cache.Get(appID, caches.BizAccessTokenLock)
+	assert.ErrorIs(t, err, caches.ErrKeyNotFound)
+}
+
+func TestTokenLockWaitForHolder(t *testing.T) {
+	cache := caches.NewDummyCache()
+	staleToken, _ := client.SerializeToken(client.NewToken("stale", 3600))
+	cache.Set(appID, caches.BizAccessToken, staleToken, 3600)
+	cache.Set(appID, caches.BizAccessTokenLock, []byte("other"), 10)
+
+	akc := &slowAccessTokenClient{}
+	config := client.Config{
+		AccessTokenClient: akc,
+		Cache:             cache,
+		TokenLock: &client.TokenLockConfig{
+			PollInterval: 10 * time.Millisecond,
+		},
+	}
+	c := client.New(auth, config)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		newToken, _ := client.SerializeToken(client.NewToken("new", 3600))
+		cache.Set(appID, caches.BizAccessToken, newToken, 3600)
+		cache.Delete(appID, caches.BizAccessTokenLock, []byte("other"))
+	}()
+
+	token, err := c.FetchAccessToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "new", token.GetAccessToken())
+	assert.Equal(t, int32(0), akc.calls)
+}
+
+func TestTokenLockTieredCache(t *testing.T) {
+	shared := caches.NewDummyCache()
+	staleToken, _ := client.SerializeToken(client.NewToken("stale", 3600))
+	shared.Set(appID, caches.BizAccessToken, staleToken, 3600)
+	shared.Set(appID, caches.BizAccessTokenLock, []byte("other"), 10)
+
+	// Both replicas keep a local copy of the stale token
+	holder := caches.NewTiered(caches.NewDummyCache(), shared, 0)
+	cache := caches.NewTiered(caches.NewDummyCache(), shared, 0)
+	holder.Get(appID, caches.BizAccessToken)
+	cache.Get(appID, caches.BizAccessToken)
+
+	akc := &slowAccessTokenClient{}
+	config := client.Config{
+		AccessTokenClient: akc,
+		Cache:             cache,
+		TokenLock: &client.TokenLockConfig{
+			WaitTimeout:  time.Second,
+			PollInterval: 10 * time.Millisecond,
+			Fallback:     client.TokenLockFallbackError,
+		},
+	}
+	c := client.New(auth, config)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		newToken, _ := client.SerializeToken(client.NewToken("new", 3600))
+		holder.Set(appID, caches.BizAccessToken, newToken, 3600)
+		holder.Delete(appID, caches.BizAccessTokenLock, []byte("other"))
+	}()
+
+	token, err := c.FetchAccessToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "new", token.GetAccessToken())
+	assert.Equal(t, int32(0), akc.calls)
+}
+
+func TestTokenLockRejectedToken(t *testing.T) {
+	// The token has been renewed by another process after the rejected one
+	cache := caches.NewDummyCache()
+	renewedToken, _ := client.SerializeToken(client.NewToken("renewed", 3600))
+	cache.Set(appID, caches.BizAccessToken, renewedToken, 3600)
+
+	akc := &slowAccessTokenClient{}
+	c := client.New(auth, client.Config{
+		AccessTokenClient: akc,
+		Cache:             cache,
+		TokenLock:         &client.TokenLockConfig{},
+	})
+
+	ctx := client.WithRejectedToken(context.Background(), client.NewToken("rejected", 3600))
+	token, err := c.FetchAccessTokenContext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "renewed", token.GetAccessToken())
+	assert.Equal(t, int32(0), akc.calls)
+}
+
+// Another process stores its token and releases the lock right after the first read
+type racingCache struct {
+	caches.Cache
+	once sync.Once
+}
+
+func (c *racingCache) Get(appId string, key string) ([]byte, error) {
+	if key == caches.BizAccessToken {
+		var missed bool
+		c.once.Do(func() {
+			missed = true
+			newToken, _ := client.SerializeToken(client.NewToken("new", 3600))
+			c.Cache.Set(appId, caches.BizAccessToken, newToken, 3600)
+		})
+		if missed {
+			return nil, caches.ErrKeyNotFound
+		}
+	}
+	return c.Cache.Get(appId, key)
+}
+
+func TestTokenLockRecheckAfterAcquired(t *testing.T) {
+	akc := &slowAccessTokenClient{}
+	config := client.Config{
+		AccessTokenClient: akc,
+		Cache:             &racingCache{Cache: caches.NewDummyCache()},
+		TokenLock:         &client.TokenLockConfig{},
+	}
+	c := client.New(auth, config)
+
+	token, err := c.FetchAccessToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "new", token.GetAccessToken())
+	assert.Equal(t, int32(0), akc.calls)
+}
+
+func TestTokenLockHolderDied(t *testing.T) {
+	cache := caches.NewDummyCache()
+	cache.Set(appID, caches.BizAccessTokenLock, []byte("other"), 1)
+
+	akc := &slowAccessTokenClient{}
+	config := client.Config{
+		AccessTokenClient: akc,
+		Cache:             cache,
+		TokenLock: &client.TokenLockConfig{
+			WaitTimeout:  3 * time.Second,
+			PollInterval: 10 * time.Millisecond,
+			Fallback:     client.TokenLockFallbackError,
+		},
+	}
+	c := client.New(auth, config)
+
+	token, err := c.FetchAccessToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token.GetAccessToken())
+	assert.Equal(t, int32(1), akc.calls)
+}
+
+func TestTokenLockTimeout(t *testing.T) {
+	cache := caches.NewDummyCache()
+	cache.Set(appID, caches.BizAccessTokenLock, []byte("other"), 10)
+
+	akc := &slowAccessTokenClient{}
+	config := client.Config{
+		AccessTokenClient: akc,
+		Cache:             cache,
+		TokenLock: &client.TokenLockConfig{
+			WaitTimeout:  50 * time.Millisecond,
+			PollInterval: 10 * time.Millisecond,
+			Fallback:     client.TokenLockFallbackError,
+		},
+	}
+	c := client.New(auth, config)
+
+	_, err := c.FetchAccessToken()
+	assert.ErrorIs(t, err, client.ErrTokenLockTimeout)
+	assert.Equal(t, int32(0), akc.calls)
+
+	config.TokenLock.Fallback = client.TokenLockFallbackFetch
+	c = client.New(auth, config)
+
+	token, err := c.FetchAccessToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token.GetAccessToken())
+	assert.Equal(t, int32(1), akc.calls)
+}
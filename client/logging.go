@@ -0,0 +1,216 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Replaces the sensitive values in logs
+const RedactedValue = "REDACTED"
+
+// Keys of the query parameters and JSON fields which are never logged
+var sensitiveKeys = map[string]bool{
+	"access_token":             true,
+	"secret":                   true,
+	"appsecret":                true,
+	"app_secret":               true,
+	"component_appsecret":      true,
+	"component_access_token":   true,
+	"component_verify_ticket":  true,
+	"authorizer_access_token":  true,
+	"authorizer_refresh_token": true,
+	"refresh_token":            true,
+	"session_key":              true,
+	"ticket":                   true,
+}
+
+func isSensitiveKey(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// Returns the url with sensitive query parameters redacted
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	query := u.Query()
+	for key := range query {
+		if isSensitiveKey(key) {
+			query.Set(key, RedactedValue)
+		}
+	}
+	rv := *u
+	rv.RawQuery = query.Encode()
+	rv.User = nil
+	return rv.String()
+}
+
+// Returns the JSON with sensitive fields redacted, or nil if it is not a valid JSON
+func redactJSON(data []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil
+	}
+	rv, _ := json.Marshal(redactValue(v))
+	return rv
+}
+
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for key, value := range t {
+			if isSensitiveKey(key) {
+				t[key] = RedactedValue
+			} else {
+				t[key] = redactValue(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range t {
+			t[i] = redactValue(value)
+		}
+	}
+	return v
+}
+
+// Returns the message of the error, urls in it are redacted
+func redactError(err error) string {
+	msg := err.Error()
+	var urlError *url.Error
+	if errors.As(err, &urlError) {
+		if u, parseErr := url.Parse(urlError.URL); parseErr == nil {
+			msg = strings.ReplaceAll(msg, urlError.URL, redactURL(u))
+		}
+	}
+	return msg
+}
+
+// Wraps the error of sending a request, the url held by it is redacted so that
+// the credentials never reach the caller, the logs or the `Observer`
+func sendingError(err error) error {
+	var urlError *url.Error
+	if errors.As(err, &urlError) {
+		if u, parseErr := url.Parse(urlError.URL); parseErr == nil {
+			urlError.URL = redactURL(u)
+		}
+	}
+	return fmt.Errorf("sending request failed: %w", err)
+}
+
+func (c *weChatClient) logAttempt(ctx context.Context, req *http.Request, resp *http.Response, err error, duration time.Duration) {
+	if c.logger == nil {
+		return
+	}
+
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelWarn
+	}
+	if !c.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.String("url", redactURL(req.URL)),
+		slog.Duration("duration", duration),
+		resultLogAttr(err),
+	}
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", redactError(err)))
+	}
+	if c.logger.Enabled(ctx, slog.LevelDebug) {
+		if body := getRequestBody(req); body != nil {
+			attrs = append(attrs, slog.String("request_body", string(body)))
+		}
+		if body := getResponseBody(resp); body != nil {
+			attrs = append(attrs, slog.String("response_body", string(body)))
+		}
+	}
+
+	c.logger.LogAttrs(ctx, level, "wechat api request", attrs...)
+}
+
+func (c *weChatClient) logRetry(ctx context.Context, req *http.Request, reason string, attempt int, cause error) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(
+		ctx,
+		slog.LevelInfo,
+		"retrying wechat api request",
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.String("reason", reason),
+		slog.Int("attempt", attempt+1),
+		resultLogAttr(cause),
+		slog.String("error", redactError(cause)),
+	)
+}
+
+func (c *weChatClient) logTokenFetch(ctx context.Context, duration time.Duration, err error) {
+	if c.logger == nil {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("appid", c.auth.GetAppId()),
+		slog.Duration("duration", duration),
+		resultLogAttr(err),
+	}
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("error", redactError(err)))
+	}
+	c.logger.LogAttrs(ctx, level, "fetched wechat access token", attrs...)
+}
+
+// Returns the errcode of an operation, or the error class if the error is not from WeChat server
+func resultLogAttr(err error) slog.Attr {
+	errCode, class := classifyError(err)
+	if class != "" {
+		return slog.String("error_class", class)
+	}
+	return slog.Int("errcode", errCode)
+}
+
+// Returns the redacted JSON body of the request if it can be replayed
+func getRequestBody(req *http.Request) []byte {
+	if req.GetBody == nil || !strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil
+	}
+	return redactJSON(data)
+}
+
+// Returns the redacted JSON body of the response, the body is buffered for later reading
+func getResponseBody(resp *http.Response) []byte {
+	if resp == nil || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return nil
+	}
+	var data json.RawMessage
+	if err := GetJson(resp, &data); err != nil {
+		return nil
+	}
+	return redactJSON(data)
+}
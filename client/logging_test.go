@@ -0,0 +1,92 @@
+package client_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/Xavier-Lam/go-wechat/client"
+	"github.com/Xavier-Lam/go-wechat/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogging(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		if calls == 1 {
+			return test.Responses.Json(`{"errcode": 40014, "errmsg": "invalid access_token"}`)
+		}
+		return test.Responses.Json(`{"errcode": 0, "session_key": "secret-session-key", "openid": "openid"}`)
+	})
+
+	cache := caches.NewDummyCache()
+	serializedToken, _ := client.SerializeToken(client.NewToken("stale-token-value", 3600))
+	cache.Set(appID, caches.BizAccessToken, serializedToken, 3600)
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := client.New(auth, client.Config{
+		AccessTokenClient: test.NewMockAccessTokenClient("fresh-token-value"),
+		Cache:             cache,
+		HttpClient:        mc,
+		Logger:            logger,
+	})
+
+	data := map[string]interface{}{
+		"appid":  appID,
+		"secret": "secret-value",
+		"nested": []interface{}{map[string]interface{}{"ticket": "ticket-value"}},
+	}
+	_, err := c.PostJson("https://api.weixin.qq.com/some-endpoint?ticket=ticket-value&foo=bar", data, true)
+	assert.NoError(t, err)
+
+	output := buf.String()
+	assert.NotContains(t, output, "stale-token-value")
+	assert.NotContains(t, output, "fresh-token-value")
+	assert.NotContains(t, output, "secret-value")
+	assert.NotContains(t, output, "ticket-value")
+	assert.NotContains(t, output, "secret-session-key")
+	assert.Contains(t, output, client.RedactedValue)
+	assert.Contains(t, output, `"level":"WARN"`)
+	assert.Contains(t, output, `"reason":"`+client.RetryReasonToken+`"`)
+	assert.Contains(t, output, `"errcode":40014`)
+	assert.Contains(t, output, "foo=bar")
+	assert.Contains(t, output, `\"openid\":\"openid\"`)
+}
+
+func TestLoggingDisabled(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		return test.Responses.Json(`{"errcode": 0}`)
+	})
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelError}))
+	c := client.New(auth, client.Config{
+		AccessTokenClient: test.NewMockAccessTokenClient("token"),
+		HttpClient:        mc,
+		Logger:            logger,
+	})
+
+	_, err := c.Get("https://api.weixin.qq.com/some-endpoint", true)
+	assert.NoError(t, err)
+	assert.Empty(t, buf.String())
+}
+
+func TestLoggingErrorClass(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		return nil, errors.New("connection reset")
+	})
+
+	buf := &bytes.Buffer{}
+	c := client.New(auth, client.Config{
+		HttpClient: mc,
+		Logger:     slog.New(slog.NewJSONHandler(buf, nil)),
+	})
+
+	_, err := c.Get("https://api.weixin.qq.com/some-endpoint", false)
+	assert.Error(t, err)
+	assert.Contains(t, buf.String(), `"error_class":"`+client.ErrorClassTransport+`"`)
+	assert.NotContains(t, buf.String(), "errcode")
+}
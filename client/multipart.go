@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// The form field of the uploaded file
+const MultipartFileField = "media"
+
+// Returned when a request with a body which cannot be replayed is about to be retried
+var ErrBodyNotReplayable = errors.New("the request body cannot be replayed")
+
+// MultipartFile is a file uploaded as the `media` field of a multipart/form-data request
+type MultipartFile struct {
+	Reader      io.Reader // Content of the file, the request can only be retried if it implements `io.Seeker`
+	Filename    string    // WeChat validates the type of the file by its extension
+	ContentType string    // Content type of the file, default value is 'application/octet-stream'
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// multipartBody streams a multipart/form-data body through a pipe without buffering the file.
+// The writer starts on the first read, so an unread body holds no goroutine.
+type multipartBody struct {
+	boundary string
+	file     *MultipartFile
+	fields   map[string]string
+
+	once sync.Once
+	pr   *io.PipeReader
+	done chan struct{}
+}
+
+func newMultipartBody(boundary string, file *MultipartFile, fields map[string]string) *multipartBody {
+	return &multipartBody{
+		boundary: boundary,
+		file:     file,
+		fields:   fields,
+		done:     make(chan struct{}),
+	}
+}
+
+func (b *multipartBody) start() {
+	pr, pw := io.Pipe()
+	b.pr = pr
+	go func() {
+		defer close(b.done)
+		pw.CloseWithError(b.write(pw))
+	}()
+}
+
+func (b *multipartBody) write(w io.Writer) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(b.boundary); err != nil {
+		return err
+	}
+	for key, value := range b.fields {
+		if err := mw.WriteField(key, value); err != nil {
+			return err
+		}
+	}
+
+	contentType := b.file.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(
+		`form-data; name="%s"; filename="%s"`,
+		MultipartFileField,
+		quoteEscaper.Replace(b.file.Filename),
+	))
+	h.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, b.file.Reader); err != nil {
+		return err
+	}
+	return mw.Close()
+}
+
+func (b *multipartBody) Read(p []byte) (int, error) {
+	b.once.Do(b.start)
+	if b.pr == nil {
+		// Closed before being read
+		return 0, io.ErrClosedPipe
+	}
+	return b.pr.Read(p)
+}
+
+// Close stops the writer and waits for it, so that the file is no longer read once it returns
+func (b *multipartBody) Close() error {
+	// Prevents the writer from being started by later reads
+	b.once.Do(func() {})
+	if b.pr != nil {
+		b.pr.CloseWithError(io.ErrClosedPipe)
+		<-b.done
+	}
+	return nil
+}
+
+// Returns a function creating the body of every attempt to send the request,
+// the previous body is closed and the file is rewound before a new one is created.
+func multipartBodyFactory(file *MultipartFile, fields map[string]string) (func() (io.ReadCloser, error), string, error) {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	seeker, seekable := file.Reader.(io.Seeker)
+	var offset int64
+	if seekable {
+		var err error
+		if offset, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			return nil, "", err
+		}
+	}
+
+	var mu sync.Mutex
+	var current *multipartBody
+	factory := func() (io.ReadCloser, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if current != nil {
+			if !seekable {
+				return nil, ErrBodyNotReplayable
+			}
+			current.Close()
+			if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+		current = newMultipartBody(boundary, file, fields)
+		return current, nil
+	}
+	return factory, "multipart/form-data; boundary=" + boundary, nil
+}
+
+func newMultipartRequest(ctx context.Context, url string, file *MultipartFile, fields map[string]string) (*http.Request, error) {
+	factory, contentType, err := multipartBodyFactory(file, fields)
+	if err != nil {
+		return nil, err
+	}
+	body, _ := factory()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if _, ok := file.Reader.(io.Seeker); ok {
+		req.GetBody = factory
+	}
+	return req, nil
+}
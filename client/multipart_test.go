@@ -0,0 +1,158 @@
+package client_test
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/Xavier-Lam/go-wechat/client"
+	"github.com/Xavier-Lam/go-wechat/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type multipartContent struct {
+	filename    string
+	contentType string
+	content     string
+	fields      map[string]string
+}
+
+func readMultipart(t *testing.T, req *http.Request) multipartContent {
+	mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/form-data", mediaType)
+
+	rv := multipartContent{fields: map[string]string{}}
+	reader := multipart.NewReader(req.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF || !assert.NoError(t, err) {
+			break
+		}
+		data, err := io.ReadAll(part)
+		assert.NoError(t, err)
+		if part.FormName() == client.MultipartFileField {
+			rv.filename = part.FileName()
+			rv.contentType = part.Header.Get("Content-Type")
+			rv.content = string(data)
+		} else {
+			rv.fields[part.FormName()] = string(data)
+		}
+	}
+	return rv
+}
+
+func newInvalidTokenClient(mc client.HttpClient) client.WeChatClient {
+	cache := caches.NewDummyCache()
+	serializedToken, _ := client.SerializeToken(client.NewToken("invalid", 3600))
+	cache.Set(appID, caches.BizAccessToken, serializedToken, 3600)
+	return client.New(auth, client.Config{
+		AccessTokenClient: test.NewMockAccessTokenClient("token"),
+		Cache:             cache,
+		HttpClient:        mc,
+	})
+}
+
+func TestPostMultipart(t *testing.T) {
+	content := strings.Repeat("content", 10000)
+	fields := map[string]string{"description": `{"title": "title"}`}
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, "POST", req.Method)
+		rv := readMultipart(t, req)
+		assert.Equal(t, "file.jpg", rv.filename)
+		assert.Equal(t, "image/jpeg", rv.contentType)
+		assert.Equal(t, content, rv.content)
+		assert.Equal(t, fields, rv.fields)
+		req.Body.Close()
+
+		if calls == 1 {
+			assert.Equal(t, "invalid", req.URL.Query().Get("access_token"))
+			return test.Responses.Json(`{"errcode": 40014, "errmsg": "invalid access_token"}`)
+		}
+		assert.Equal(t, "token", req.URL.Query().Get("access_token"))
+		return test.Responses.Json(`{"errcode": 0}`)
+	})
+	c := newInvalidTokenClient(mc)
+
+	// Skipped bytes are not uploaded
+	reader := strings.NewReader("skipped" + content)
+	reader.Seek(int64(len("skipped")), io.SeekStart)
+	file := &client.MultipartFile{
+		Reader:      reader,
+		Filename:    "file.jpg",
+		ContentType: "image/jpeg",
+	}
+	_, err := c.PostMultipart("https://api.weixin.qq.com/some-endpoint", file, fields, true)
+	assert.NoError(t, err)
+}
+
+func TestPostMultipartNotReplayable(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, 1, calls)
+		rv := readMultipart(t, req)
+		assert.Equal(t, "application/octet-stream", rv.contentType)
+		req.Body.Close()
+		return test.Responses.Json(`{"errcode": 40014, "errmsg": "invalid access_token"}`)
+	})
+	c := newInvalidTokenClient(mc)
+
+	file := &client.MultipartFile{
+		Reader:   io.MultiReader(bytes.NewBufferString("content")),
+		Filename: "file.jpg",
+	}
+	_, err := c.PostMultipart("https://api.weixin.qq.com/some-endpoint", file, nil, true)
+	assert.ErrorContains(t, err, client.ErrBodyNotReplayable.Error())
+	assert.ErrorIs(t, err, client.ErrAuth)
+}
+
+func TestPostMultipartNotReplayableRetryPolicy(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, 1, calls)
+		readMultipart(t, req)
+		return test.Responses.Json(`{"errcode": -1, "errmsg": "system error"}`)
+	})
+	c := client.New(auth, client.Config{
+		AccessTokenClient: test.NewMockAccessTokenClient("token"),
+		HttpClient:        mc,
+		RetryPolicy: &client.RetryPolicy{
+			MaxAttempts:        3,
+			ErrCodes:           []int{client.ErrCodeSystemBusy},
+			InitialBackoff:     time.Second,
+			RetryNonIdempotent: true,
+		},
+	})
+
+	// Fails at once without waiting for the backoff
+	start := time.Now()
+	file := &client.MultipartFile{
+		Reader:   io.MultiReader(bytes.NewBufferString("content")),
+		Filename: "file.jpg",
+	}
+	_, err := c.PostMultipart("https://api.weixin.qq.com/some-endpoint", file, nil, true)
+	assert.Equal(t, client.ErrCodeSystemBusy, err.(client.WeChatApiError).ErrCode)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestPostMultipartUnread(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		if calls == 1 {
+			// The body is closed without being read
+			req.Body.Close()
+			return test.Responses.Json(`{"errcode": 40014, "errmsg": "invalid access_token"}`)
+		}
+		rv := readMultipart(t, req)
+		assert.Equal(t, "content", rv.content)
+		return test.Responses.Json(`{"errcode": 0}`)
+	})
+	c := newInvalidTokenClient(mc)
+
+	file := &client.MultipartFile{Reader: strings.NewReader("content"), Filename: "file.jpg"}
+	_, err := c.PostMultipart("https://api.weixin.qq.com/some-endpoint", file, nil, true)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Names of the spans and metrics emitted by `WeChatClient`
+const (
+	SpanTokenFetch  = "wechat.token.fetch"  // Fetching an access token from WeChat server
+	SpanHttpAttempt = "wechat.http.attempt" // Each attempt to send an API request
+
+	MetricTokenCacheHit  = "wechat.token.cache.hit"  // Access token served from cache
+	MetricTokenCacheMiss = "wechat.token.cache.miss" // Access token not found in cache
+	MetricTokenFetch     = "wechat.token.fetch"      // Access token fetched from WeChat server
+	MetricHttpDuration   = "wechat.http.duration"    // Latency of each attempt to send an API request
+	MetricApiResult      = "wechat.api.result"       // Outcome of each attempt, labeled with the errcode or the error class
+	MetricRetry          = "wechat.retry"            // Retries of API requests, labeled with the reason
+
+	AttrAppId       = "wechat.appid"
+	AttrErrCode     = "wechat.errcode"     // Set for success and the errors responded by WeChat server
+	AttrErrorClass  = "wechat.error.class" // Set for the errors not from WeChat server instead of `AttrErrCode`
+	AttrRetryReason = "wechat.retry.reason"
+	AttrPath        = "http.path"
+	AttrMethod      = "http.method"
+	AttrStatusCode  = "http.status_code"
+
+	RetryReasonToken  = "token"  // Retried after refreshing a rejected access token
+	RetryReasonPolicy = "policy" // Retried as the `RetryPolicy` allows
+
+	ErrorClassHttpStatus  = "http_status"        // WeChat server responded a non-2xx status
+	ErrorClassMalformed   = "malformed_response" // The response could not be parsed
+	ErrorClassRateLimited = "rate_limited"       // Rejected by the client-side rate limiter
+	ErrorClassCanceled    = "canceled"           // The context was canceled or its deadline exceeded
+	ErrorClassTransport   = "transport"          // Sending the request or reading the response failed
+)
+
+// Attribute is a key-value pair attached to spans and metrics,
+// the value is either a string, an int or a bool.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Observer receives the traces and metrics of a `WeChatClient`
+type Observer interface {
+	// StartSpan starts a span, the returned function ends it with the error of the operation
+	StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, func(err error))
+
+	// Count adds to a counter
+	Count(ctx context.Context, name string, value int64, attrs ...Attribute)
+
+	// RecordDuration records a duration in a histogram
+	RecordDuration(ctx context.Context, name string, duration time.Duration, attrs ...Attribute)
+}
+
+type noopObserver struct{}
+
+// NoopObserver discards everything, it is the default `Observer`
+var NoopObserver Observer = noopObserver{}
+
+func (noopObserver) StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, func(err error)) {
+	return ctx, func(err error) {}
+}
+
+func (noopObserver) Count(ctx context.Context, name string, value int64, attrs ...Attribute) {}
+
+func (noopObserver) RecordDuration(ctx context.Context, name string, duration time.Duration, attrs ...Attribute) {
+}
+
+// Classifies the result of an operation, the errcode is set for success
+// and the errors from WeChat server, the error class is set otherwise.
+func classifyError(err error) (errCode int, class string) {
+	var apiError WeChatApiError
+	switch {
+	case err == nil:
+		return 0, ""
+	case errors.As(err, &apiError):
+		return apiError.ErrCode, ""
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return 0, ErrorClassCanceled
+	case errors.Is(err, ErrRateLimitExceeded):
+		return 0, ErrorClassRateLimited
+	case errors.As(err, &HTTPStatusError{}):
+		return 0, ErrorClassHttpStatus
+	case errors.As(err, &MalformedResponseError{}):
+		return 0, ErrorClassMalformed
+	}
+	return 0, ErrorClassTransport
+}
+
+// Returns the attribute labeling the result of an operation
+func resultAttribute(err error) Attribute {
+	errCode, class := classifyError(err)
+	if class != "" {
+		return Attribute{AttrErrorClass, class}
+	}
+	return Attribute{AttrErrCode, errCode}
+}
@@ -0,0 +1,193 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/Xavier-Lam/go-wechat/client"
+	"github.com/Xavier-Lam/go-wechat/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedSpan struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+}
+
+type recordingObserver struct {
+	mu        sync.Mutex
+	spans     []recordedSpan
+	counters  map[string]int64
+	durations map[string]int
+	attrs     map[string][]map[string]interface{}
+}
+
+func newRecordingObserver() *recordingObserver {
+	return &recordingObserver{
+		counters:  make(map[string]int64),
+		durations: make(map[string]int),
+		attrs:     make(map[string][]map[string]interface{}),
+	}
+}
+
+func toMap(attrs []client.Attribute) map[string]interface{} {
+	rv := make(map[string]interface{})
+	for _, attr := range attrs {
+		rv[attr.Key] = attr.Value
+	}
+	return rv
+}
+
+func (o *recordingObserver) StartSpan(ctx context.Context, name string, attrs ...client.Attribute) (context.Context, func(err error)) {
+	return ctx, func(err error) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		o.spans = append(o.spans, recordedSpan{name, toMap(attrs), err})
+	}
+}
+
+func (o *recordingObserver) Count(ctx context.Context, name string, value int64, attrs ...client.Attribute) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.counters[name] += value
+	o.attrs[name] = append(o.attrs[name], toMap(attrs))
+}
+
+func (o *recordingObserver) RecordDuration(ctx context.Context, name string, duration time.Duration, attrs ...client.Attribute) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.durations[name]++
+}
+
+func TestObserver(t *testing.T) {
+	expectedUrl := "https://api.weixin.qq.com/some-endpoint"
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		if calls == 1 {
+			return test.Responses.Json(`{"errcode": 40014, "errmsg": "invalid access_token"}`)
+		}
+		return test.Responses.Empty()
+	})
+
+	cache := caches.NewDummyCache()
+	serializedToken, _ := client.SerializeToken(client.NewToken("invalid", 3600))
+	cache.Set(appID, caches.BizAccessToken, serializedToken, 3600)
+
+	obs := newRecordingObserver()
+	c := client.New(auth, client.Config{
+		AccessTokenClient: test.NewMockAccessTokenClient("token"),
+		Cache:             cache,
+		HttpClient:        mc,
+		Observer:          obs,
+	})
+
+	_, err := c.Get(expectedUrl, true)
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(1), obs.counters[client.MetricTokenCacheHit])
+	assert.Equal(t, int64(0), obs.counters[client.MetricTokenCacheMiss])
+	assert.Equal(t, int64(1), obs.counters[client.MetricTokenFetch])
+	assert.Equal(t, int64(2), obs.counters[client.MetricApiResult])
+	assert.Equal(t, int64(1), obs.counters[client.MetricRetry])
+	assert.Equal(t, 2, obs.durations[client.MetricHttpDuration])
+
+	results := obs.attrs[client.MetricApiResult]
+	assert.Equal(t, 40014, results[0][client.AttrErrCode])
+	assert.Equal(t, 0, results[1][client.AttrErrCode])
+	assert.Equal(t, "/some-endpoint", results[1][client.AttrPath])
+	assert.Equal(t, "GET", results[1][client.AttrMethod])
+	assert.Equal(t, 200, results[1][client.AttrStatusCode])
+	assert.Equal(t, client.RetryReasonToken, obs.attrs[client.MetricRetry][0][client.AttrRetryReason])
+
+	names := []string{}
+	for _, span := range obs.spans {
+		names = append(names, span.name)
+	}
+	assert.Equal(t, []string{client.SpanHttpAttempt, client.SpanTokenFetch, client.SpanHttpAttempt}, names)
+	assert.Error(t, obs.spans[0].err)
+	assert.NoError(t, obs.spans[2].err)
+
+	// cache miss
+	cache.Delete(appID, caches.BizAccessToken, nil)
+	_, err = c.GetAccessToken()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), obs.counters[client.MetricTokenCacheMiss])
+	assert.Equal(t, int64(2), obs.counters[client.MetricTokenFetch])
+}
+
+func TestObserverErrorClass(t *testing.T) {
+	badGateway := httptest.NewRecorder()
+	badGateway.WriteHeader(http.StatusBadGateway)
+
+	cases := []struct {
+		resp  *http.Response
+		err   error
+		class string
+	}{
+		{nil, errors.New("connection reset"), client.ErrorClassTransport},
+		{nil, context.DeadlineExceeded, client.ErrorClassCanceled},
+		{badGateway.Result(), nil, client.ErrorClassHttpStatus},
+	}
+	for _, tc := range cases {
+		mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+			return tc.resp, tc.err
+		})
+		obs := newRecordingObserver()
+		c := client.New(auth, client.Config{
+			HttpClient: mc,
+			Observer:   obs,
+		})
+
+		_, err := c.Get("https://api.weixin.qq.com/some-endpoint", false)
+		assert.Error(t, err)
+
+		result := obs.attrs[client.MetricApiResult][0]
+		assert.Equal(t, tc.class, result[client.AttrErrorClass])
+		assert.NotContains(t, result, client.AttrErrCode)
+	}
+}
+
+func TestObserverRedactedError(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		if calls == 2 {
+			return test.Responses.Json(`{"access_token": "mock-access-token", "expires_in": 7200}`)
+		}
+		return nil, &url.Error{Op: req.Method, URL: req.URL.String(), Err: errors.New("connection reset")}
+	})
+	obs := newRecordingObserver()
+	c := client.New(auth, client.Config{
+		HttpClient: mc,
+		Observer:   obs,
+	})
+
+	// Fetching token failed
+	_, err := c.Get("https://api.weixin.qq.com/some-endpoint", true)
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), appSecret)
+
+	// Sending request failed
+	_, err = c.Get("https://api.weixin.qq.com/some-endpoint", true)
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "mock-access-token")
+
+	var names []string
+	for _, span := range obs.spans {
+		if span.err == nil {
+			continue
+		}
+		names = append(names, span.name)
+		var urlError *url.Error
+		assert.ErrorAs(t, span.err, &urlError)
+		assert.NotContains(t, span.err.Error(), appSecret)
+		assert.NotContains(t, span.err.Error(), "mock-access-token")
+		assert.Contains(t, span.err.Error(), client.RedactedValue)
+	}
+	assert.Equal(t, []string{client.SpanTokenFetch, client.SpanHttpAttempt}, names)
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
+)
+
+const rateLimitLockPollInterval = 10 * time.Millisecond
+
+// ErrRateLimitExceeded is returned when a request is rejected by the client-side rate limiter,
+// it matches `ErrRateLimited` via `errors.Is`.
+var ErrRateLimitExceeded = fmt.Errorf("%w: client-side rate limit exceeded", ErrRateLimited)
+
+// RateLimit configures a token bucket
+type RateLimit struct {
+	Rate  float64 // Tokens added to the bucket per second
+	Burst int     // Capacity of the bucket, default value is 1
+}
+
+type RateLimiterConfig struct {
+	Default *RateLimit           // Limit of the paths not listed in `Paths`, unlimited if not given
+	Paths   map[string]RateLimit // Limits of API paths, such as '/cgi-bin/user/info'
+	Cache   caches.Cache         // Shares the buckets among processes, buckets are kept in memory if not given
+	Wait    bool                 // Wait until a token is available or the context is done, fail fast with `ErrRateLimitExceeded` otherwise
+}
+
+type bucketState struct {
+	Tokens    float64   `json:"tokens"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Takes a token from the bucket, returns how long to wait for the next token if the bucket is empty
+func (s *bucketState) take(limit RateLimit, now time.Time) time.Duration {
+	if s.UpdatedAt.IsZero() {
+		s.Tokens = float64(limit.Burst)
+	} else if elapsed := now.Sub(s.UpdatedAt); elapsed > 0 {
+		s.Tokens = math.Min(float64(limit.Burst), s.Tokens+elapsed.Seconds()*limit.Rate)
+	}
+	s.UpdatedAt = now
+
+	if s.Tokens >= 1 {
+		s.Tokens--
+		return 0
+	}
+	return time.Duration((1 - s.Tokens) / limit.Rate * float64(time.Second))
+}
+
+type rateLimiter struct {
+	appId  string
+	config RateLimiterConfig
+	mu     sync.Mutex
+	local  map[string]*bucketState
+}
+
+func newRateLimiter(appId string, conf RateLimiterConfig) *rateLimiter {
+	return &rateLimiter{
+		appId:  appId,
+		config: conf,
+		local:  make(map[string]*bucketState),
+	}
+}
+
+// An `Interceptor` taking a token before sending a request
+func (l *rateLimiter) intercept(req *http.Request, next Invoker) (*http.Response, error) {
+	if err := l.wait(req.Context(), req.URL.Path); err != nil {
+		return nil, err
+	}
+	return next(req)
+}
+
+// Takes a token for the path, waits for it if `Wait` is configured
+func (l *rateLimiter) wait(ctx context.Context, path string) error {
+	limit, ok := l.config.Paths[path]
+	if !ok {
+		if l.config.Default == nil {
+			return nil
+		}
+		limit = *l.config.Default
+	}
+	if limit.Rate <= 0 {
+		return nil
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = 1
+	}
+
+	for {
+		wait, err := l.take(ctx, path, limit)
+		if err != nil {
+			return err
+		}
+		if wait == 0 {
+			return nil
+		}
+
+		if !l.config.Wait {
+			return ErrRateLimitExceeded
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return ErrRateLimitExceeded
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (l *rateLimiter) take(ctx context.Context, path string, limit RateLimit) (time.Duration, error) {
+	if l.config.Cache == nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		state, ok := l.local[path]
+		if !ok {
+			state = &bucketState{}
+			l.local[path] = state
+		}
+		return state.take(limit, time.Now()), nil
+	}
+
+	biz := caches.BizRateLimit + ":" + path
+	unlock, err := l.lock(ctx, biz+":lock")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	state := &bucketState{}
+	if value, err := getShared(l.config.Cache, l.appId, biz); err == nil {
+		json.Unmarshal(value, state)
+	}
+	wait := state.take(limit, time.Now())
+
+	value, err := json.Marshal(state)
+	if err != nil {
+		return 0, err
+	}
+	// Keep the state until the bucket is full again
+	expiresIn := int(math.Ceil(float64(limit.Burst)/limit.Rate)) + 1
+	if err := l.config.Cache.Set(l.appId, biz, value, expiresIn); err != nil {
+		return 0, err
+	}
+	return wait, nil
+}
+
+// Locks the bucket shared in cache, returns the function to release the lock
+func (l *rateLimiter) lock(ctx context.Context, biz string) (func(), error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	value := []byte(hex.EncodeToString(b))
+
+	for {
+		err := l.config.Cache.Add(l.appId, biz, value, 1)
+		if err == nil {
+			return func() {
+				l.config.Cache.Delete(l.appId, biz, value)
+			}, nil
+		} else if err != caches.ErrKeyExisted {
+			return nil, err
+		}
+
+		timer := time.NewTimer(rateLimitLockPollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/Xavier-Lam/go-wechat/client"
+	"github.com/Xavier-Lam/go-wechat/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRateLimitedClient(conf client.RateLimiterConfig) client.WeChatClient {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		return test.Responses.Empty()
+	})
+	return client.New(auth, client.Config{
+		HttpClient:  mc,
+		RateLimiter: &conf,
+	})
+}
+
+func TestRateLimiterFailFast(t *testing.T) {
+	c := newRateLimitedClient(client.RateLimiterConfig{
+		Paths: map[string]client.RateLimit{
+			"/cgi-bin/user/info": {Rate: 0.1, Burst: 2},
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := c.Get("/cgi-bin/user/info", false)
+		assert.NoError(t, err)
+	}
+	_, err := c.Get("/cgi-bin/user/info", false)
+	assert.ErrorIs(t, err, client.ErrRateLimitExceeded)
+	assert.True(t, errors.Is(err, client.ErrRateLimited))
+
+	// unlimited
+	for i := 0; i < 5; i++ {
+		_, err = c.Get("/cgi-bin/message/custom/send", false)
+		assert.NoError(t, err)
+	}
+}
+
+func TestRateLimiterDefault(t *testing.T) {
+	c := newRateLimitedClient(client.RateLimiterConfig{
+		Default: &client.RateLimit{Rate: 0.1},
+	})
+
+	_, err := c.Get("/cgi-bin/user/info", false)
+	assert.NoError(t, err)
+	_, err = c.Get("/cgi-bin/user/info", false)
+	assert.ErrorIs(t, err, client.ErrRateLimitExceeded)
+	_, err = c.Get("/cgi-bin/message/custom/send", false)
+	assert.NoError(t, err)
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	c := newRateLimitedClient(client.RateLimiterConfig{
+		Default: &client.RateLimit{Rate: 20},
+		Wait:    true,
+	})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := c.Get("/cgi-bin/user/info", false)
+		assert.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	// the deadline is too close to wait
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	_, err := c.GetContext(ctx, "/cgi-bin/user/info", false)
+	assert.ErrorIs(t, err, client.ErrRateLimitExceeded)
+	assert.Less(t, time.Since(start), 10*time.Millisecond)
+}
+
+func TestRateLimiterSharedCache(t *testing.T) {
+	cache := caches.NewDummyCache()
+	conf := client.RateLimiterConfig{
+		Default: &client.RateLimit{Rate: 0.1, Burst: 1},
+		Cache:   cache,
+	}
+	c1 := newRateLimitedClient(conf)
+	c2 := newRateLimitedClient(conf)
+
+	_, err := c1.Get("/cgi-bin/user/info", false)
+	assert.NoError(t, err)
+	_, err = c2.Get("/cgi-bin/user/info", false)
+	assert.ErrorIs(t, err, client.ErrRateLimitExceeded)
+
+	_, err = cache.Get(appID, caches.BizRateLimit+":/cgi-bin/user/info")
+	assert.NoError(t, err)
+}
+
+func TestRateLimiterTieredCache(t *testing.T) {
+	shared := caches.NewDummyCache()
+	c1 := newRateLimitedClient(client.RateLimiterConfig{
+		Default: &client.RateLimit{Rate: 0.1, Burst: 2},
+		Cache:   caches.NewTiered(caches.NewDummyCache(), shared, 0),
+	})
+	c2 := newRateLimitedClient(client.RateLimiterConfig{
+		Default: &client.RateLimit{Rate: 0.1, Burst: 2},
+		Cache:   caches.NewTiered(caches.NewDummyCache(), shared, 0),
+	})
+
+	_, err := c2.Get("/cgi-bin/user/info", false)
+	assert.NoError(t, err)
+	_, err = c1.Get("/cgi-bin/user/info", false)
+	assert.NoError(t, err)
+	// The local copy of c2 is outdated
+	_, err = c2.Get("/cgi-bin/user/info", false)
+	assert.ErrorIs(t, err, client.ErrRateLimitExceeded)
+}
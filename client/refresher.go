@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultRefreshThreshold = 0.2
+	DefaultRefreshInterval  = time.Minute
+)
+
+// Refreshable represents a credential which can be renewed by `Refresher` before it expires
+type Refreshable interface {
+	// Expiry returns when the credential expires and its whole lifetime.
+	// A zero time means the expiry is unknown and the credential should be refreshed.
+	Expiry(ctx context.Context) (time.Time, time.Duration)
+
+	// Refresh renews the credential
+	Refresh(ctx context.Context) error
+}
+
+type RefresherConfig struct {
+	Threshold    float64       // Refresh a credential when the share of its lifetime left is less than it, default value is 0.2
+	Interval     time.Duration // Interval between two checks, default value is 1 minute
+	Jitter       time.Duration // Max random delay added to each interval, default value is 10% of `Interval`
+	ErrorHandler func(error)   // Called when a refresh failed, errors are ignored if not given
+}
+
+// Refresher renews credentials in background, so user-facing requests never pay the refresh latency
+type Refresher struct {
+	config RefresherConfig
+	items  []Refreshable
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartRefresher creates a `Refresher` for the given credentials and starts it.
+// It runs until `ctx` is done or `Stop` is called.
+func StartRefresher(ctx context.Context, conf RefresherConfig, items ...Refreshable) *Refresher {
+	if conf.Threshold <= 0 {
+		conf.Threshold = DefaultRefreshThreshold
+	}
+	if conf.Interval <= 0 {
+		conf.Interval = DefaultRefreshInterval
+	}
+	if conf.Jitter <= 0 {
+		conf.Jitter = conf.Interval / 10
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	r := &Refresher{
+		config: conf,
+		items:  items,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go r.run(ctx)
+	return r
+}
+
+// Stop shuts the refresher down and waits for the running refresh to finish
+func (r *Refresher) Stop() {
+	r.cancel()
+	<-r.done
+}
+
+func (r *Refresher) run(ctx context.Context) {
+	defer close(r.done)
+
+	timer := time.NewTimer(r.jitter())
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		r.refresh(ctx)
+		timer.Reset(r.config.Interval + r.jitter())
+	}
+}
+
+func (r *Refresher) refresh(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, item := range r.items {
+		expiresAt, lifetime := item.Expiry(ctx)
+		left := time.Until(expiresAt)
+		if !expiresAt.IsZero() && left > time.Duration(float64(lifetime)*r.config.Threshold) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(item Refreshable) {
+			defer wg.Done()
+			if err := item.Refresh(ctx); err != nil && r.config.ErrorHandler != nil {
+				r.config.ErrorHandler(err)
+			}
+		}(item)
+	}
+	wg.Wait()
+}
+
+func (r *Refresher) jitter() time.Duration {
+	return time.Duration(rand.Int63n(int64(r.config.Jitter) + 1))
+}
+
+type accessTokenRefreshable struct {
+	c    WeChatClient
+	mu   sync.Mutex
+	last *Token // The last token fetched by the refresher
+}
+
+// AccessTokenRefreshable returns the access token of a `WeChatClient` as a `Refreshable`.
+// The expiry is read from the `Cache` of the client, or is the one of the token
+// last fetched by the refresher if there is no `Cache` set up.
+func AccessTokenRefreshable(c WeChatClient) Refreshable {
+	return &accessTokenRefreshable{c: c}
+}
+
+func (r *accessTokenRefreshable) Expiry(ctx context.Context) (time.Time, time.Duration) {
+	var token *Token
+	if c, ok := r.c.(*weChatClient); ok && c.cache != nil {
+		token = c.cachedAccessToken()
+	} else {
+		r.mu.Lock()
+		token = r.last
+		r.mu.Unlock()
+	}
+	if token == nil {
+		return time.Time{}, 0
+	}
+	return token.GetExpiresAt(), time.Duration(token.expiresIn) * time.Second
+}
+
+func (r *accessTokenRefreshable) Refresh(ctx context.Context) error {
+	token, err := r.c.FetchAccessTokenContext(ctx)
+	if token != nil {
+		r.mu.Lock()
+		r.last = token
+		r.mu.Unlock()
+	}
+	return err
+}
@@ -0,0 +1,125 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/Xavier-Lam/go-wechat/client"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRefreshable struct {
+	expiresAt time.Time
+	lifetime  time.Duration
+	err       error
+	calls     int32
+}
+
+func (r *mockRefreshable) Expiry(ctx context.Context) (time.Time, time.Duration) {
+	return r.expiresAt, r.lifetime
+}
+
+func (r *mockRefreshable) Refresh(ctx context.Context) error {
+	atomic.AddInt32(&r.calls, 1)
+	return r.err
+}
+
+func TestRefresher(t *testing.T) {
+	unknown := &mockRefreshable{}
+	fresh := &mockRefreshable{
+		expiresAt: time.Now().Add(time.Hour),
+		lifetime:  2 * time.Hour,
+	}
+	expiring := &mockRefreshable{
+		expiresAt: time.Now().Add(time.Minute),
+		lifetime:  2 * time.Hour,
+		err:       errors.New("refresh failed"),
+	}
+
+	var errs int32
+	r := client.StartRefresher(context.Background(), client.RefresherConfig{
+		Interval: 20 * time.Millisecond,
+		ErrorHandler: func(err error) {
+			assert.EqualError(t, err, "refresh failed")
+			atomic.AddInt32(&errs, 1)
+		},
+	}, unknown, fresh, expiring)
+
+	time.Sleep(50 * time.Millisecond)
+	r.Stop()
+
+	calls := atomic.LoadInt32(&unknown.calls)
+	assert.GreaterOrEqual(t, calls, int32(1))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&fresh.calls))
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&expiring.calls), int32(1))
+	assert.Equal(t, atomic.LoadInt32(&expiring.calls), atomic.LoadInt32(&errs))
+
+	// stopped
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, calls, atomic.LoadInt32(&unknown.calls))
+}
+
+func TestRefresherContextDone(t *testing.T) {
+	item := &mockRefreshable{}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := client.StartRefresher(ctx, client.RefresherConfig{
+		Interval: 20 * time.Millisecond,
+	}, item)
+
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	r.Stop()
+
+	calls := atomic.LoadInt32(&item.calls)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, calls, atomic.LoadInt32(&item.calls))
+}
+
+func TestAccessTokenRefreshable(t *testing.T) {
+	akc := &slowAccessTokenClient{}
+	config := client.Config{
+		AccessTokenClient: akc,
+		Cache:             caches.NewDummyCache(),
+	}
+	c := client.New(auth, config)
+	r := client.AccessTokenRefreshable(c)
+
+	// Never fetch a token for reading the expiry
+	expiresAt, _ := r.Expiry(context.Background())
+	assert.True(t, expiresAt.IsZero())
+	assert.Equal(t, int32(0), akc.calls)
+
+	_, err := c.GetAccessToken()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), akc.calls)
+
+	expiresAt, lifetime := r.Expiry(context.Background())
+	assert.WithinDuration(t, time.Now().Add(client.DefaultTokenExpiresIn*time.Second), expiresAt, time.Second)
+	assert.Equal(t, client.DefaultTokenExpiresIn*time.Second, lifetime)
+
+	err = r.Refresh(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), akc.calls)
+
+	r.Expiry(context.Background())
+	assert.Equal(t, int32(2), akc.calls)
+}
+
+func TestAccessTokenRefreshableWithoutCache(t *testing.T) {
+	akc := &slowAccessTokenClient{}
+	c := client.New(auth, client.Config{AccessTokenClient: akc})
+	refresher := client.StartRefresher(
+		context.Background(),
+		client.RefresherConfig{Interval: 10 * time.Millisecond},
+		client.AccessTokenRefreshable(c),
+	)
+	time.Sleep(100 * time.Millisecond)
+	refresher.Stop()
+
+	// Only the first tick fetches a token, the others keep its expiry
+	assert.Equal(t, int32(1), atomic.LoadInt32(&akc.calls))
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// GetJSON sends a GET request with access_token to the path of WeChat API,
+// the JSON response is decoded into a `T` and the body is closed.
+func GetJSON[T any](ctx context.Context, c WeChatClient, path string, query url.Values) (*T, error) {
+	resp, err := c.GetContext(ctx, withQuery(path, query), true)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON[T](resp)
+}
+
+// PostJSON sends a POST request with access_token and JSON encoded `data` to the path of WeChat API,
+// the JSON response is decoded into a `Resp` and the body is closed.
+func PostJSON[Req, Resp any](ctx context.Context, c WeChatClient, path string, data Req) (*Resp, error) {
+	resp, err := c.PostJsonContext(ctx, path, data, true)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON[Resp](resp)
+}
+
+// GetStream sends a GET request with access_token to the path of WeChat API,
+// the binary response is returned without being buffered.
+func GetStream(ctx context.Context, c WeChatClient, path string, query url.Values) (*Stream, error) {
+	resp, err := c.GetContext(ctx, withQuery(path, query), true)
+	if err != nil {
+		return nil, err
+	}
+	return NewStream(resp), nil
+}
+
+// PostStream sends a POST request with access_token and JSON encoded `data` to the path of WeChat API,
+// the binary response is returned without being buffered.
+func PostStream[Req any](ctx context.Context, c WeChatClient, path string, data Req) (*Stream, error) {
+	resp, err := c.PostJsonContext(ctx, path, data, true)
+	if err != nil {
+		return nil, err
+	}
+	return NewStream(resp), nil
+}
+
+// PostMultipartJSON uploads the file as the `media` field with the extra fields to the path of WeChat API,
+// the JSON response is decoded into a `Resp` and the body is closed.
+func PostMultipartJSON[Resp any](ctx context.Context, c WeChatClient, path string, query url.Values, file *MultipartFile, fields map[string]string) (*Resp, error) {
+	resp, err := c.PostMultipartContext(ctx, withQuery(path, query), file, fields, true)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON[Resp](resp)
+}
+
+// Appends the query to the path, values already in the path are kept
+func withQuery(path string, query url.Values) string {
+	if len(query) == 0 {
+		return path
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return path
+	}
+	q := u.Query()
+	for key, values := range query {
+		for _, value := range values {
+			q.Add(key, value)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func decodeJSON[T any](resp *http.Response) (*T, error) {
+	rv := new(T)
+	if err := GetJson(resp, rv); err != nil {
+		return nil, MalformedResponseError{Err: err}
+	}
+	return rv, nil
+}
@@ -0,0 +1,104 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat/client"
+	"github.com/Xavier-Lam/go-wechat/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type trackedBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+type typedResponse struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestGetJSON(t *testing.T) {
+	body := &trackedBody{Reader: strings.NewReader(`{"name": "foo", "count": 2}`)}
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, "GET", req.Method)
+		test.AssertEndpointEqual(t, "https://api.weixin.qq.com/some-endpoint", req.URL)
+		assert.Equal(t, "1", req.URL.Query().Get("a"))
+		assert.Equal(t, "b c", req.URL.Query().Get("b"))
+		assert.Equal(t, "token", req.URL.Query().Get("access_token"))
+
+		resp, _ := test.Responses.Empty()
+		resp.Body = body
+		return resp, nil
+	})
+	c := client.New(auth, client.Config{
+		AccessTokenClient: test.NewMockAccessTokenClient("token"),
+		HttpClient:        mc,
+	})
+
+	rv, err := client.GetJSON[typedResponse](context.Background(), c, "/some-endpoint?a=1", url.Values{"b": {"b c"}})
+	assert.NoError(t, err)
+	assert.Equal(t, &typedResponse{"foo", 2}, rv)
+	assert.True(t, body.closed)
+}
+
+func TestPostJSON(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, "POST", req.Method)
+		test.AssertEndpointEqual(t, "https://api.weixin.qq.com/some-endpoint", req.URL)
+		assert.Equal(t, "token", req.URL.Query().Get("access_token"))
+
+		data := typedResponse{}
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&data))
+		assert.Equal(t, typedResponse{"bar", 1}, data)
+
+		return test.Responses.Json(`{"errcode": 0, "name": "foo", "count": 2}`)
+	})
+	c := client.New(auth, client.Config{
+		AccessTokenClient: test.NewMockAccessTokenClient("token"),
+		HttpClient:        mc,
+	})
+
+	rv, err := client.PostJSON[typedResponse, typedResponse](context.Background(), c, "/some-endpoint", typedResponse{"bar", 1})
+	assert.NoError(t, err)
+	assert.Equal(t, &typedResponse{"foo", 2}, rv)
+}
+
+func TestTypedRequestError(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		resp, _ := test.Responses.Empty()
+		resp.Header.Set("Content-Type", "text/plain")
+		if calls == 1 {
+			resp.Body = io.NopCloser(strings.NewReader(`{"errcode": 40003, "errmsg": "invalid openid rid: 6543e1b2-1a2b3c4d-5e6f7a8b"}`))
+		} else {
+			resp.Body = io.NopCloser(strings.NewReader(`not a json`))
+		}
+		return resp, nil
+	})
+	c := client.New(auth, client.Config{
+		AccessTokenClient: test.NewMockAccessTokenClient("token"),
+		HttpClient:        mc,
+	})
+
+	_, err := client.GetJSON[typedResponse](context.Background(), c, "/some-endpoint", nil)
+	var apiError client.WeChatApiError
+	assert.True(t, errors.As(err, &apiError))
+	assert.Equal(t, 40003, apiError.ErrCode)
+	assert.Equal(t, "6543e1b2-1a2b3c4d-5e6f7a8b", apiError.Rid)
+
+	_, err = client.GetJSON[typedResponse](context.Background(), c, "/some-endpoint", nil)
+	var malformed client.MalformedResponseError
+	assert.True(t, errors.As(err, &malformed))
+}
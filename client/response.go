@@ -0,0 +1,80 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// The number of bytes inspected to tell whether a response is a JSON object
+const sniffLength = 512
+
+// Stream is a binary response of WeChat API, such as a media file or a QR code image.
+// The caller must close it after reading.
+type Stream struct {
+	io.ReadCloser
+	ContentType   string
+	ContentLength int64 // -1 if unknown
+	Filename      string
+}
+
+// NewStream wraps the body of a response, the filename is read from the Content-Disposition header
+func NewStream(resp *http.Response) *Stream {
+	rv := &Stream{
+		ReadCloser:    resp.Body,
+		ContentType:   resp.Header.Get("Content-Type"),
+		ContentLength: resp.ContentLength,
+	}
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		rv.Filename = params["filename"]
+	}
+	return rv
+}
+
+// Reads the whole body and closes it, the body of the response is replaced by the read bytes
+func readBody(resp *http.Response) ([]byte, error) {
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, err
+}
+
+func isJSONContentType(resp *http.Response) bool {
+	return strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json")
+}
+
+// Reports whether the body of a textual response seems to be a JSON object, the body is not consumed.
+// Binary responses are never sniffed so that they can be streamed.
+func sniffJSON(resp *http.Response) bool {
+	if isJSONContentType(resp) {
+		return true
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/") {
+		return false
+	}
+
+	br := bufio.NewReaderSize(resp.Body, sniffLength)
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{br, resp.Body}
+	for i := 1; i <= sniffLength; i++ {
+		peeked, err := br.Peek(i)
+		if err != nil {
+			return false
+		}
+		switch peeked[i-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '{':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
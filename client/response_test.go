@@ -0,0 +1,65 @@
+package client_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat/client"
+	"github.com/Xavier-Lam/go-wechat/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetJsonClosesBody(t *testing.T) {
+	body := &trackedBody{Reader: strings.NewReader(`{"name": "foo"}`)}
+	resp, _ := test.Responses.Empty()
+	resp.Body = body
+
+	data := map[string]string{}
+	err := client.GetJson(resp, &data)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", data["name"])
+	assert.True(t, body.closed)
+
+	// The body can be read again
+	content, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name": "foo"}`, string(content))
+}
+
+func TestSniffJSONError(t *testing.T) {
+	cases := map[string]struct {
+		contentType string
+		body        string
+		isError     bool
+	}{
+		"text json error":    {"text/plain", "\n  {\"errcode\": 40001, \"errmsg\": \"invalid credential\"}", true},
+		"text json":          {"text/plain", `{"errcode": 0, "name": "foo"}`, false},
+		"text":               {"text/plain", `plain text`, false},
+		"binary json prefix": {"image/jpeg", `{"errcode": 40001, "errmsg": "invalid credential"}`, false},
+		"html":               {"text/html; charset=utf-8", `<html></html>`, false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+				resp, _ := test.Responses.Empty()
+				resp.Header.Set("Content-Type", tc.contentType)
+				resp.Body = io.NopCloser(strings.NewReader(tc.body))
+				return resp, nil
+			})
+			c := client.New(auth, client.Config{HttpClient: mc})
+
+			resp, err := c.Get("https://api.weixin.qq.com/some-endpoint", false)
+			if tc.isError {
+				assert.ErrorIs(t, err, client.ErrAuth)
+				return
+			}
+			assert.NoError(t, err)
+			content, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.body, string(content))
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultRetryInitialBackoff = 100 * time.Millisecond
+	DefaultRetryMaxBackoff     = 5 * time.Second
+	DefaultRetryMultiplier     = 2
+	DefaultRetryJitter         = 0.2
+)
+
+// DefaultRetryPolicy retries system errors of WeChat server at most twice
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	ErrCodes:    []int{ErrCodeSystemBusy},
+	HttpStatuses: []int{
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+}
+
+// RetryPolicy decides whether and when a failed request is sent again.
+// Retrying after refreshing a rejected access token is not limited by the policy.
+type RetryPolicy struct {
+	MaxAttempts        int           // Max attempts of a request including the first one, never retry if less than 2
+	ErrCodes           []int         // WeChat error codes to retry
+	HttpStatuses       []int         // HTTP status codes to retry
+	InitialBackoff     time.Duration // Delay before the first retry, default value is 100ms
+	MaxBackoff         time.Duration // Max delay between two attempts, default value is 5s
+	Multiplier         float64       // Factor the delay grows by after each retry, default value is 2
+	Jitter             float64       // Share of the delay randomized, default value is 0.2
+	RetryNonIdempotent bool          // Retry POST requests not marked by `WithIdempotent`
+}
+
+type idempotentKey struct{}
+
+// WithIdempotent marks the requests sent with the context safe to retry,
+// for instance a POST request only querying data.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	idempotent, _ := req.Context().Value(idempotentKey{}).(bool)
+	return idempotent
+}
+
+func newRetryPolicy(policy RetryPolicy) *RetryPolicy {
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = DefaultRetryMultiplier
+	}
+	if policy.Jitter <= 0 || policy.Jitter > 1 {
+		policy.Jitter = DefaultRetryJitter
+	}
+	return &policy
+}
+
+// Whether a request failed at the given attempt should be retried
+func (p *RetryPolicy) shouldRetry(attempt int, req *http.Request, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	if req.Body != nil && req.GetBody == nil {
+		// The body cannot be replayed
+		return false
+	}
+	if !p.RetryNonIdempotent && !isIdempotent(req) {
+		return false
+	}
+
+	if apiError, ok := err.(WeChatApiError); ok {
+		return containsInt(p.ErrCodes, apiError.ErrCode)
+	}
+	if statusError, ok := err.(HTTPStatusError); ok {
+		return containsInt(p.HttpStatuses, statusError.StatusCode)
+	}
+	return false
+}
+
+// Sleeps before the next attempt, returns the error of `ctx` if it is done earlier
+func (p *RetryPolicy) wait(ctx context.Context, attempt int) error {
+	backoff := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	backoff = math.Min(backoff, float64(p.MaxBackoff))
+	backoff *= 1 - p.Jitter + 2*p.Jitter*rand.Float64()
+
+	timer := time.NewTimer(time.Duration(backoff))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Chains the errors of all attempts, the error of a later attempt is
+// the `RetryError` of the one before.
+func chainRetryErrors(errs []error) error {
+	err := errs[len(errs)-1]
+	for i := len(errs) - 2; i >= 0; i-- {
+		if apiError, ok := errs[i].(WeChatApiError); ok {
+			apiError.RetryError = err
+			err = apiError
+		} else {
+			err = fmt.Errorf("%w (Retry error: %w)", errs[i], err)
+		}
+	}
+	return err
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
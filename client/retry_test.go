@@ -0,0 +1,164 @@
+package client_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Xavier-Lam/go-wechat/client"
+	"github.com/Xavier-Lam/go-wechat/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+var retryPolicy = client.RetryPolicy{
+	MaxAttempts:    3,
+	ErrCodes:       []int{client.ErrCodeSystemBusy},
+	HttpStatuses:   []int{http.StatusBadGateway},
+	InitialBackoff: time.Millisecond,
+}
+
+func TestRetryPolicy(t *testing.T) {
+	expectedUrl := "https://api.weixin.qq.com/some-endpoint"
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		if calls == 1 {
+			return test.Responses.Json(`{"errcode": -1, "errmsg": "system error"}`)
+		} else if calls == 2 {
+			recorder := httptest.NewRecorder()
+			recorder.WriteHeader(http.StatusBadGateway)
+			return recorder.Result(), nil
+		}
+		return test.Responses.Empty()
+	})
+
+	c := client.New(auth, client.Config{
+		HttpClient:  mc,
+		RetryPolicy: &retryPolicy,
+	})
+
+	resp, err := c.Get(expectedUrl, false)
+	assert.NoError(t, err)
+	assert.Equal(t, emptyResponse, resp)
+}
+
+func TestRetryPolicyExhausted(t *testing.T) {
+	expectedUrl := "https://api.weixin.qq.com/some-endpoint"
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		if calls > 3 {
+			assert.Fail(t, "Unexpected calls")
+		}
+		return test.Responses.Json(`{"errcode": -1, "errmsg": "system error"}`)
+	})
+
+	c := client.New(auth, client.Config{
+		HttpClient:  mc,
+		RetryPolicy: &retryPolicy,
+	})
+
+	_, err := c.Get(expectedUrl, false)
+	apiError := err.(client.WeChatApiError)
+	assert.Equal(t, -1, apiError.ErrCode)
+	apiError = apiError.RetryError.(client.WeChatApiError)
+	assert.Equal(t, -1, apiError.ErrCode)
+	apiError = apiError.RetryError.(client.WeChatApiError)
+	assert.Equal(t, -1, apiError.ErrCode)
+	assert.Nil(t, apiError.RetryError)
+}
+
+func TestRetryPolicyErrorChain(t *testing.T) {
+	expectedUrl := "https://api.weixin.qq.com/some-endpoint"
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		if calls == 1 {
+			recorder := httptest.NewRecorder()
+			recorder.WriteHeader(http.StatusBadGateway)
+			return recorder.Result(), nil
+		}
+		return test.Responses.Json(`{"errcode": 45009, "errmsg": "reach max api daily quota limit"}`)
+	})
+
+	c := client.New(auth, client.Config{
+		HttpClient:  mc,
+		RetryPolicy: &retryPolicy,
+	})
+
+	// The error of every attempt can be matched
+	_, err := c.Get(expectedUrl, false)
+	var statusError client.HTTPStatusError
+	assert.ErrorAs(t, err, &statusError)
+	assert.Equal(t, http.StatusBadGateway, statusError.StatusCode)
+	var apiError client.WeChatApiError
+	assert.ErrorAs(t, err, &apiError)
+	assert.Equal(t, client.ErrCodeApiQuotaExceeded, apiError.ErrCode)
+	assert.ErrorIs(t, err, client.ErrQuotaExceeded)
+}
+
+func TestRetryPolicyErrCodeNotMatched(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, 1, calls)
+		return test.Responses.Json(`{"errcode": 45009, "errmsg": "api freq out of limit"}`)
+	})
+
+	c := client.New(auth, client.Config{
+		HttpClient:  mc,
+		RetryPolicy: &retryPolicy,
+	})
+
+	_, err := c.Get("https://api.weixin.qq.com/some-endpoint", false)
+	assert.Equal(t, client.ErrCodeApiQuotaExceeded, err.(client.WeChatApiError).ErrCode)
+}
+
+func TestRetryPolicyIdempotency(t *testing.T) {
+	expectedUrl := "https://api.weixin.qq.com/some-endpoint"
+	data := map[string]string{"key": "value"}
+
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, 1, calls)
+		return test.Responses.Json(`{"errcode": -1, "errmsg": "system error"}`)
+	})
+	c := client.New(auth, client.Config{
+		HttpClient:  mc,
+		RetryPolicy: &retryPolicy,
+	})
+	_, err := c.PostJson(expectedUrl, data, false)
+	assert.Error(t, err)
+
+	// marked as idempotent, the body is replayed
+	mc = test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.JSONEq(t, `{"key": "value"}`, string(body))
+		if calls == 1 {
+			return test.Responses.Json(`{"errcode": -1, "errmsg": "system error"}`)
+		}
+		return test.Responses.Empty()
+	})
+	c = client.New(auth, client.Config{
+		HttpClient:  mc,
+		RetryPolicy: &retryPolicy,
+	})
+	ctx := client.WithIdempotent(context.Background())
+	resp, err := c.PostJsonContext(ctx, expectedUrl, data, false)
+	assert.NoError(t, err)
+	assert.Equal(t, emptyResponse, resp)
+}
+
+func TestRetryPolicyContextDone(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, 1, calls)
+		return test.Responses.Json(`{"errcode": -1, "errmsg": "system error"}`)
+	})
+
+	policy := retryPolicy
+	policy.InitialBackoff = time.Second
+	c := client.New(auth, client.Config{
+		HttpClient:  mc,
+		RetryPolicy: &policy,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.GetContext(ctx, "https://api.weixin.qq.com/some-endpoint", false)
+	assert.Equal(t, -1, err.(client.WeChatApiError).ErrCode)
+	assert.ErrorIs(t, err.(client.WeChatApiError).RetryError, context.DeadlineExceeded)
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,10 +13,35 @@ import (
 )
 
 const (
-	DefaultAccessTokenUri = "https://api.weixin.qq.com/cgi-bin/token"
-	DefaultTokenExpiresIn = 7200
+	DefaultAccessTokenUri       = "https://api.weixin.qq.com/cgi-bin/token"
+	DefaultStableAccessTokenUri = "https://api.weixin.qq.com/cgi-bin/stable_token"
+	DefaultTokenExpiresIn       = 7200
 )
 
+type forceRefreshKey struct{}
+
+// Marks the token fetched with the context should be renewed even if the current one is still valid
+func withForceRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceRefreshKey{}, true)
+}
+
+func isForceRefresh(ctx context.Context) bool {
+	force, _ := ctx.Value(forceRefreshKey{}).(bool)
+	return force
+}
+
+type rejectedTokenKey struct{}
+
+// Marks the token fetched with the context replaces the given one rejected by WeChat server
+func withRejectedToken(ctx context.Context, token *Token) context.Context {
+	return context.WithValue(ctx, rejectedTokenKey{}, token)
+}
+
+func getRejectedToken(ctx context.Context) *Token {
+	token, _ := ctx.Value(rejectedTokenKey{}).(*Token)
+	return token
+}
+
 type tokenResponse struct {
 	AccessToken string `json:"access_token"`
 	ExpiresIn   int    `json:"expires_in"`
@@ -56,9 +83,25 @@ func (t *Token) GetExpiresAt() time.Time {
 }
 
 type AccessTokenClient interface {
+	// GetAccessToken requests a new access token from WeChat server
 	GetAccessToken(auth wechat.Auth) (*Token, error)
 }
 
+// AccessTokenContextClient is implemented by an `AccessTokenClient` supporting context,
+// `WeChatClient` prefers it so that fetching a token is bound to the context of the request.
+type AccessTokenContextClient interface {
+	// GetAccessTokenContext requests a new access token from WeChat server with the given context
+	GetAccessTokenContext(ctx context.Context, auth wechat.Auth) (*Token, error)
+}
+
+// Requests a new access token, the context is passed on if the client supports it
+func requestAccessToken(ctx context.Context, akc AccessTokenClient, auth wechat.Auth) (*Token, error) {
+	if contextClient, ok := akc.(AccessTokenContextClient); ok {
+		return contextClient.GetAccessTokenContext(ctx, auth)
+	}
+	return akc.GetAccessToken(auth)
+}
+
 type accessTokenClient struct {
 	http     HttpClient
 	endpoint *url.URL // The endpoint to request a new token, default value is 'https://api.weixin.qq.com/cgi-bin/token'
@@ -72,6 +115,10 @@ func NewAccessTokenClient(endpoint *url.URL, http HttpClient) AccessTokenClient
 }
 
 func (c *accessTokenClient) GetAccessToken(auth wechat.Auth) (*Token, error) {
+	return c.GetAccessTokenContext(context.Background(), auth)
+}
+
+func (c *accessTokenClient) GetAccessTokenContext(ctx context.Context, auth wechat.Auth) (*Token, error) {
 	// Build url
 	uri := c.endpoint.String()
 	query := url.Values{
@@ -82,15 +129,67 @@ func (c *accessTokenClient) GetAccessToken(auth wechat.Auth) (*Token, error) {
 	uri += "?" + query.Encode()
 
 	// Prepare request
-	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return requestToken(c.http, req)
+}
+
+type stableAccessTokenClient struct {
+	http     HttpClient
+	endpoint *url.URL // The endpoint to request a new token, default value is 'https://api.weixin.qq.com/cgi-bin/stable_token'
+}
+
+type stableTokenRequest struct {
+	GrantType    string `json:"grant_type"`
+	AppId        string `json:"appid"`
+	Secret       string `json:"secret"`
+	ForceRefresh bool   `json:"force_refresh"`
+}
+
+// NewStableAccessTokenClient creates an `AccessTokenClient` requesting the stable_token endpoint.
+// A stable token does not invalidate the tokens obtained before,
+// it is force refreshed only when the token is rejected by WeChat server.
+// https://developers.weixin.qq.com/doc/offiaccount/Basic_Information/getStableAccessToken.html
+func NewStableAccessTokenClient(endpoint *url.URL, http HttpClient) AccessTokenClient {
+	return &stableAccessTokenClient{
+		http:     http,
+		endpoint: endpoint,
+	}
+}
+
+func (c *stableAccessTokenClient) GetAccessToken(auth wechat.Auth) (*Token, error) {
+	return c.GetAccessTokenContext(context.Background(), auth)
+}
+
+func (c *stableAccessTokenClient) GetAccessTokenContext(ctx context.Context, auth wechat.Auth) (*Token, error) {
+	data, err := json.Marshal(&stableTokenRequest{
+		GrantType:    "client_credential",
+		AppId:        auth.GetAppId(),
+		Secret:       auth.GetAppSecret(),
+		ForceRefresh: isForceRefresh(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepare request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint.String(), bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return requestToken(c.http, req)
+}
 
+func requestToken(client HttpClient, req *http.Request) (*Token, error) {
 	// Send request
-	resp, err := c.http.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("sending request failed: %w", err)
+		return nil, sendingError(err)
 	}
 
 	// Handle response
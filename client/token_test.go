@@ -1,12 +1,16 @@
 package client_test
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"testing"
 	"time"
 
 	"github.com/Xavier-Lam/go-wechat"
+	"github.com/Xavier-Lam/go-wechat/caches"
 	"github.com/Xavier-Lam/go-wechat/client"
 	"github.com/Xavier-Lam/go-wechat/internal/test"
 	"github.com/stretchr/testify/assert"
@@ -69,3 +73,71 @@ func TestTokenSerialize(t *testing.T) {
 	assert.WithinDuration(t, token.GetExpiresAt(), deserializedToken.GetExpiresAt(), time.Millisecond*50)
 	assert.Equal(t, token.GetExpiresIn(), deserializedToken.GetExpiresIn())
 }
+
+func TestStableTokenGetAccessToken(t *testing.T) {
+	auth := wechat.NewAuth("app-id", "app-secret")
+
+	httpClient := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, "POST", req.Method)
+		test.AssertEndpointEqual(t, client.DefaultStableAccessTokenUri, req.URL)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.JSONEq(t, `{
+			"grant_type": "client_credential",
+			"appid": "app-id",
+			"secret": "app-secret",
+			"force_refresh": false
+		}`, string(body))
+
+		return test.Responses.Json(`{"access_token": "access-token", "expires_in": 7200}`)
+	})
+	url, _ := url.Parse(client.DefaultStableAccessTokenUri)
+	akc := client.NewStableAccessTokenClient(url, httpClient).(client.AccessTokenContextClient)
+
+	token, err := akc.GetAccessTokenContext(context.Background(), auth)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "access-token", token.GetAccessToken())
+	assert.Equal(t, 7200, token.GetExpiresIn())
+}
+
+func TestStableTokenForceRefresh(t *testing.T) {
+	expectedUrl := "https://api.weixin.qq.com/some-endpoint"
+	for errCode, force := range map[int]bool{
+		client.ErrCodeInvalidCredential:  true,
+		client.ErrCodeInvalidAccessToken: true,
+		client.ErrCodeAccessTokenExpired: false,
+	} {
+		mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+			if calls == 1 {
+				return test.Responses.Json(fmt.Sprintf(`{"errcode": %d, "errmsg": "error"}`, errCode))
+			} else if calls == 2 {
+				test.AssertEndpointEqual(t, client.DefaultStableAccessTokenUri, req.URL)
+				body, _ := ioutil.ReadAll(req.Body)
+				assert.Contains(t, string(body), fmt.Sprintf(`"force_refresh":%t`, force))
+				return test.Responses.Json(`{"access_token": "token", "expires_in": 7200}`)
+			} else if calls == 3 {
+				assert.Equal(t, "token", req.URL.Query().Get("access_token"))
+				return test.Responses.Empty()
+			} else {
+				assert.Fail(t, "Unexpected calls")
+				return nil, nil
+			}
+		})
+
+		tokenUrl, _ := url.Parse(client.DefaultStableAccessTokenUri)
+		cache := caches.NewDummyCache()
+		serializedToken, _ := client.SerializeToken(client.NewToken("invalid", 3600))
+		cache.Set("app-id", caches.BizAccessToken, serializedToken, 3600)
+		c := client.New(wechat.NewAuth("app-id", "app-secret"), client.Config{
+			AccessTokenClient: client.NewStableAccessTokenClient(tokenUrl, mc),
+			HttpClient:        mc,
+			Cache:             cache,
+		})
+
+		_, err := c.Get(expectedUrl, true)
+		assert.NoError(t, err)
+	}
+}
@@ -1,19 +1,28 @@
 package client
 
 import (
-	"bytes"
 	"encoding/json"
-	"io/ioutil"
 	"net/http"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
 )
 
+// GetJson decodes the body of the response into data.
+// The original body is closed and replaced by a buffer so that it can be read again.
 func GetJson(resp *http.Response, data interface{}) error {
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := readBody(resp)
 	if err != nil {
 		return err
 	}
 
-	err = json.Unmarshal(body, data)
-	resp.Body = ioutil.NopCloser(bytes.NewBuffer(body))
-	return err
+	return json.Unmarshal(body, data)
+}
+
+// Reads a value shared between processes, the local copy kept by the cache
+// is dropped first so that the changes made by other processes are seen.
+func getShared(cache caches.Cache, appId string, key string) ([]byte, error) {
+	if invalidator, ok := cache.(caches.Invalidator); ok {
+		invalidator.Invalidate(appId, key)
+	}
+	return cache.Get(appId, key)
 }
@@ -0,0 +1,71 @@
+// Package singleflight provides a duplicate call suppression mechanism,
+// so only one of the concurrent callers with the same key does the work.
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type call struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// Group represents a class of work, calls with the same key are deduplicated.
+// The zero value is ready to use.
+type Group struct {
+	Timeout time.Duration // Max time an execution may take, no limit if not given
+
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// Do executes and returns the results of the given function, making sure that
+// only one execution is in-flight for a given key at a time.
+// The execution is shared by all the callers, so it runs on a context carrying
+// the values of the first caller's `ctx` but never canceled by it.
+// Every caller waits for the results, or returns the error of its own `ctx` once it is done.
+func (g *Group) Do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	c, ok := g.calls[key]
+	if !ok {
+		c = &call{done: make(chan struct{})}
+		g.calls[key] = c
+		go g.run(ctx, key, c, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (g *Group) run(ctx context.Context, key string, c *call, fn func(ctx context.Context) (interface{}, error)) {
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	ctx = context.WithoutCancel(ctx)
+	if g.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.Timeout)
+		defer cancel()
+	}
+	c.value, c.err = fn(ctx)
+}
@@ -0,0 +1,112 @@
+package singleflight_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Xavier-Lam/go-wechat/internal/singleflight"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupDo(t *testing.T) {
+	var g singleflight.Group
+	var calls int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := g.Do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return "value", nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "value", v)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// A finished call is not reused
+	expectedErr := errors.New("error")
+	v, err := g.Do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+		return nil, expectedErr
+	})
+	assert.ErrorIs(t, err, expectedErr)
+	assert.Nil(t, v)
+}
+
+func TestGroupDoContext(t *testing.T) {
+	var g singleflight.Group
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	go g.Do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-release
+		return "value", nil
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := g.Do(ctx, "key", func(ctx context.Context) (interface{}, error) {
+		assert.Fail(t, "Unexpected call")
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	close(release)
+}
+
+func TestGroupDoDetached(t *testing.T) {
+	var g singleflight.Group
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	// The first caller gives up, the execution keeps running for the others
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := g.Do(ctx, "key", func(ctx context.Context) (interface{}, error) {
+			close(started)
+			<-release
+			return "value", ctx.Err()
+		})
+		first <- err
+	}()
+	<-started
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+
+	follower := make(chan interface{})
+	go func() {
+		v, err := g.Do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+			assert.Fail(t, "Unexpected call")
+			return nil, nil
+		})
+		assert.NoError(t, err)
+		follower <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	assert.Equal(t, "value", <-follower)
+}
+
+func TestGroupDoTimeout(t *testing.T) {
+	g := singleflight.Group{Timeout: 10 * time.Millisecond}
+	_, err := g.Do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
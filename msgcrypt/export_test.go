@@ -0,0 +1,8 @@
+package msgcrypt
+
+import "io"
+
+// Replaces the random source so that the ciphertext is deterministic
+func SetRandom(c *MsgCrypt, random io.Reader) {
+	c.random = random
+}
@@ -0,0 +1,207 @@
+// Package msgcrypt implements the safe mode encryption of the messages pushed by WeChat,
+// it is shared by the callbacks of official accounts, mini programs and WeCom.
+// https://developers.weixin.qq.com/doc/offiaccount/Message_Management/Message_encryption_and_decryption_instructions.html
+package msgcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WeChat pads the plaintext to a multiple of 32 bytes rather than the AES block size
+const blockSize = 32
+
+const (
+	encodingAESKeyLength = 43
+	randomLength         = 16
+)
+
+var (
+	ErrInvalidAESKey    = errors.New("invalid EncodingAESKey")
+	ErrInvalidSignature = errors.New("invalid message signature")
+	ErrAppIdNotMatched  = errors.New("app id of the message not matched")
+	ErrMalformedMessage = errors.New("malformed encrypted message")
+)
+
+// MsgCrypt encrypts and decrypts messages of an app in safe mode
+type MsgCrypt struct {
+	token  string
+	appId  string // The app id of an official account or a mini program, or the corp id of WeCom
+	key    []byte
+	block  cipher.Block
+	random io.Reader
+}
+
+// Envelope is the XML wrapping an encrypted message
+type Envelope struct {
+	XMLName      xml.Name `xml:"xml"`
+	ToUserName   string   `xml:"ToUserName,omitempty"`
+	Encrypt      cdata    `xml:"Encrypt"`
+	MsgSignature cdata    `xml:"MsgSignature,omitempty"`
+	TimeStamp    string   `xml:"TimeStamp,omitempty"`
+	Nonce        cdata    `xml:"Nonce,omitempty"`
+}
+
+type cdata string
+
+func (c cdata) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(struct {
+		Text string `xml:",cdata"`
+	}{string(c)}, start)
+}
+
+// New creates a `MsgCrypt` with the token and the EncodingAESKey set on the admin platform
+func New(token, encodingAESKey, appId string) (*MsgCrypt, error) {
+	if len(encodingAESKey) != encodingAESKeyLength {
+		return nil, ErrInvalidAESKey
+	}
+	key, err := base64.StdEncoding.DecodeString(encodingAESKey + "=")
+	if err != nil {
+		return nil, ErrInvalidAESKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, ErrInvalidAESKey
+	}
+	return &MsgCrypt{
+		token:  token,
+		appId:  appId,
+		key:    key,
+		block:  block,
+		random: rand.Reader,
+	}, nil
+}
+
+// Sign returns the sha1 signature of the sorted values, which is used both to verify
+// the requests from WeChat (with token, timestamp and nonce) and to sign encrypted messages.
+func Sign(values ...string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return fmt.Sprintf("%x", sha1.Sum([]byte(strings.Join(sorted, ""))))
+}
+
+// Verify reports whether the signature matches the values
+func Verify(signature string, values ...string) bool {
+	return subtle.ConstantTimeCompare([]byte(Sign(values...)), []byte(signature)) == 1
+}
+
+// Signature returns the msg_signature of an encrypted message
+func (c *MsgCrypt) Signature(timestamp, nonce, encrypted string) string {
+	return Sign(c.token, timestamp, nonce, encrypted)
+}
+
+// Decrypt verifies the msg_signature of the encrypted message and decrypts it
+func (c *MsgCrypt) Decrypt(msgSignature, timestamp, nonce, encrypted string) ([]byte, error) {
+	if !Verify(msgSignature, c.token, timestamp, nonce, encrypted) {
+		return nil, ErrInvalidSignature
+	}
+	return c.decrypt(encrypted)
+}
+
+// DecryptMessage verifies and decrypts the `Encrypt` field of the envelope XML of an inbound message
+func (c *MsgCrypt) DecryptMessage(msgSignature, timestamp, nonce string, data []byte) ([]byte, error) {
+	envelope := &struct {
+		Encrypt string `xml:"Encrypt"`
+	}{}
+	if err := xml.Unmarshal(data, envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Encrypt == "" {
+		return nil, ErrMalformedMessage
+	}
+	return c.Decrypt(msgSignature, timestamp, nonce, envelope.Encrypt)
+}
+
+// Encrypt encrypts the message and returns the signed envelope
+func (c *MsgCrypt) Encrypt(msg []byte, timestamp, nonce string) (*Envelope, error) {
+	encrypted, err := c.encrypt(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		Encrypt:      cdata(encrypted),
+		MsgSignature: cdata(c.Signature(timestamp, nonce, encrypted)),
+		TimeStamp:    timestamp,
+		Nonce:        cdata(nonce),
+	}, nil
+}
+
+// EncryptMessage encrypts an outbound message and returns the XML of its signed envelope
+func (c *MsgCrypt) EncryptMessage(msg []byte, timestamp, nonce string) ([]byte, error) {
+	envelope, err := c.Encrypt(msg, timestamp, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return xml.Marshal(envelope)
+}
+
+// The plaintext is random(16) + length(4, big endian) + msg + appId, padded with PKCS#7
+func (c *MsgCrypt) encrypt(msg []byte) (string, error) {
+	buf := &bytes.Buffer{}
+	if _, err := io.CopyN(buf, c.random, randomLength); err != nil {
+		return "", err
+	}
+	binary.Write(buf, binary.BigEndian, uint32(len(msg)))
+	buf.Write(msg)
+	buf.WriteString(c.appId)
+
+	plaintext := pad(buf.Bytes())
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(c.block, c.key[:aes.BlockSize]).CryptBlocks(ciphertext, plaintext)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func (c *MsgCrypt) decrypt(encrypted string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, ErrMalformedMessage
+	}
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, ErrMalformedMessage
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(c.block, c.key[:aes.BlockSize]).CryptBlocks(plaintext, ciphertext)
+	plaintext, err = unpad(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(plaintext) < randomLength+4 {
+		return nil, ErrMalformedMessage
+	}
+	length := binary.BigEndian.Uint32(plaintext[randomLength : randomLength+4])
+	content := plaintext[randomLength+4:]
+	if uint64(length) > uint64(len(content)) {
+		return nil, ErrMalformedMessage
+	}
+	if string(content[length:]) != c.appId {
+		return nil, ErrAppIdNotMatched
+	}
+	return content[:length], nil
+}
+
+func pad(data []byte) []byte {
+	n := blockSize - len(data)%blockSize
+	return append(data, bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func unpad(data []byte) ([]byte, error) {
+	n := int(data[len(data)-1])
+	if n < 1 || n > blockSize || n > len(data) {
+		return nil, ErrMalformedMessage
+	}
+	return data[:len(data)-n], nil
+}
@@ -0,0 +1,127 @@
+package msgcrypt_test
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat/msgcrypt"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	token          = "pamtest"
+	encodingAESKey = "abcdefghijklmnopqrstuvwxyz0123456789ABCDEFG"
+	appId          = "wxb11529c136998cb6"
+	timestamp      = "1409304348"
+	nonce          = "xxxxxx"
+	message        = "<xml><ToUserName><![CDATA[oia2Tj我是中文jewbmiOUlr6X-1crbLOvLw]]></ToUserName><FromUserName><![CDATA[gh_7f083739789a]]></FromUserName><CreateTime>1407743423</CreateTime><MsgType><![CDATA[text]]></MsgType><Content><![CDATA[hello]]></Content></xml>"
+
+	// Generated by `openssl enc -aes-256-cbc -nopad` from the message with "0123456789abcdef" as the random prefix
+	encrypted    = "Q3stYC6hdFzMh9T8HCvyDIGLsyHCgVx+pkCc9alTDKiLTCjc84LXp19/PQGmH3yrN6GNw5OxBuFrhM6/Aq+wFKkDAY37sUqepyDjxwZ95Xqe7/9q0Cq23CfKO+MCbjZwSTWUNMLjqxtu1MHtrrx+7LBWBoZdJzfQVuFiz109YWiwLj7Blmu6UFAcyKiOME9g8/Ngubdg2z1P+jcoO0WZi+KPlKHtlQpCJEE+THXmp+6QNSnHNRnMGVOZfj2HjFiO9XBmqQITWJxaPaLBzx5lb/NpTMnFVqy3fkNV5kGrEuh4lzlRSKClFcGjtICTS2SJloRl9OVsTX7BilBdsWeMSXoYfP6j8OAx90171UIVg0awWyWhxHLnLeNdB7TjK+eqZTCnDnwLFFXVki3m/+Du2lrnsAa4cfVmmJsRkrBy6fQ="
+	msgSignature = "c3b4d66f5d8be92931906439668e22122326992f"
+)
+
+func newMsgCrypt(t *testing.T) *msgcrypt.MsgCrypt {
+	c, err := msgcrypt.New(token, encodingAESKey, appId)
+	assert.NoError(t, err)
+	msgcrypt.SetRandom(c, strings.NewReader("0123456789abcdef"))
+	return c
+}
+
+// The sample of verifying the callback URL of WeCom
+// https://developer.work.weixin.qq.com/document/path/90968
+func TestDecryptSample(t *testing.T) {
+	c, err := msgcrypt.New("QDG6eK", "jWmYm7qr5nMoAUwZRjGtBxmz3KA1tkAj3ykkR6q2B2C", "wx5823bf96d3bd56c7")
+	assert.NoError(t, err)
+
+	echoStr, err := c.Decrypt(
+		"5c45ff5e21c57e6ad56bac8758b79b1d9ac89fd3",
+		"1409659589",
+		"263014780",
+		"P9nAzCzyDtyTWESHep1vC5X9xho/qYX3Zpb4yKa9SKld1DsH3Iyt3tP3zNdtp+4RPcs8TgAE7OaBO+FZXvnaqQ==",
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "1616140317555161061", string(echoStr))
+}
+
+func TestEncrypt(t *testing.T) {
+	c := newMsgCrypt(t)
+
+	envelope, err := c.Encrypt([]byte(message), timestamp, nonce)
+	assert.NoError(t, err)
+	assert.EqualValues(t, encrypted, envelope.Encrypt)
+	assert.EqualValues(t, msgSignature, envelope.MsgSignature)
+	assert.Equal(t, timestamp, envelope.TimeStamp)
+	assert.EqualValues(t, nonce, envelope.Nonce)
+
+	msgcrypt.SetRandom(c, strings.NewReader("0123456789abcdef"))
+	data, err := c.EncryptMessage([]byte(message), timestamp, nonce)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"<xml><Encrypt><![CDATA["+encrypted+"]]></Encrypt><MsgSignature><![CDATA["+msgSignature+"]]></MsgSignature>"+
+			"<TimeStamp>"+timestamp+"</TimeStamp><Nonce><![CDATA["+nonce+"]]></Nonce></xml>",
+		string(data),
+	)
+}
+
+func TestDecrypt(t *testing.T) {
+	c := newMsgCrypt(t)
+
+	msg, err := c.Decrypt(msgSignature, timestamp, nonce, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, message, string(msg))
+
+	data := "<xml><ToUserName><![CDATA[gh_7f083739789a]]></ToUserName><Encrypt><![CDATA[" + encrypted + "]]></Encrypt></xml>"
+	msg, err = c.DecryptMessage(msgSignature, timestamp, nonce, []byte(data))
+	assert.NoError(t, err)
+	assert.Equal(t, message, string(msg))
+
+	// Round trip with a random prefix
+	c, _ = msgcrypt.New(token, encodingAESKey, appId)
+	data1, err := c.EncryptMessage([]byte(message), timestamp, nonce)
+	assert.NoError(t, err)
+	data2, _ := c.EncryptMessage([]byte(message), timestamp, nonce)
+	assert.NotEqual(t, data1, data2)
+	envelope := msgcrypt.Envelope{}
+	assert.NoError(t, xml.Unmarshal(data1, &envelope))
+	msg, err = c.DecryptMessage(string(envelope.MsgSignature), timestamp, nonce, data1)
+	assert.NoError(t, err)
+	assert.Equal(t, message, string(msg))
+}
+
+func TestDecryptErrors(t *testing.T) {
+	c := newMsgCrypt(t)
+
+	_, err := c.Decrypt("invalid", timestamp, nonce, encrypted)
+	assert.ErrorIs(t, err, msgcrypt.ErrInvalidSignature)
+
+	_, err = c.DecryptMessage(msgSignature, timestamp, nonce, []byte("<xml></xml>"))
+	assert.ErrorIs(t, err, msgcrypt.ErrMalformedMessage)
+
+	malformed := "not base64!"
+	_, err = c.Decrypt(c.Signature(timestamp, nonce, malformed), timestamp, nonce, malformed)
+	assert.ErrorIs(t, err, msgcrypt.ErrMalformedMessage)
+
+	malformed = "AAAA"
+	_, err = c.Decrypt(c.Signature(timestamp, nonce, malformed), timestamp, nonce, malformed)
+	assert.ErrorIs(t, err, msgcrypt.ErrMalformedMessage)
+
+	other, _ := msgcrypt.New(token, encodingAESKey, "wx-other-app-id")
+	_, err = other.Decrypt(msgSignature, timestamp, nonce, encrypted)
+	assert.ErrorIs(t, err, msgcrypt.ErrAppIdNotMatched)
+
+	_, err = msgcrypt.New(token, "too-short", appId)
+	assert.ErrorIs(t, err, msgcrypt.ErrInvalidAESKey)
+	_, err = msgcrypt.New(token, strings.Repeat("!", 43), appId)
+	assert.ErrorIs(t, err, msgcrypt.ErrInvalidAESKey)
+}
+
+func TestSign(t *testing.T) {
+	// The signature of verifying the server URL of an official account
+	signature := msgcrypt.Sign("token", "1409304348", "1215372165")
+	assert.Equal(t, signature, msgcrypt.Sign("1215372165", "token", "1409304348"))
+	assert.True(t, msgcrypt.Verify(signature, "token", "1409304348", "1215372165"))
+	assert.False(t, msgcrypt.Verify(signature, "token", "1409304348", "0"))
+}
@@ -5,8 +5,10 @@ import "github.com/Xavier-Lam/go-wechat/client"
 type Apis struct {
 	client.WeChatClient
 
-	Js   Js
-	User User
+	Js      Js
+	Media   Media
+	Openapi Openapi
+	User    User
 }
 
 func NewApis(c client.WeChatClient) *Apis {
@@ -14,6 +16,8 @@ func NewApis(c client.WeChatClient) *Apis {
 		c,
 
 		newJs(c),
+		newMedia(c),
+		newOpenapi(c),
 		newUser(c),
 	}
 }
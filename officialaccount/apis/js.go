@@ -1,6 +1,9 @@
 package apis
 
 import (
+	"context"
+	"net/url"
+
 	"github.com/Xavier-Lam/go-wechat/client"
 )
 
@@ -15,6 +18,9 @@ type Js interface {
 	// Get the latest validate ticket
 	// https://developers.weixin.qq.com/doc/offiaccount/OA_Web_Apps/JS-SDK.html#54
 	GetTicket() (*JSTicket, error)
+
+	// Get the latest validate ticket with the given context
+	GetTicketContext(ctx context.Context) (*JSTicket, error)
 }
 
 type js struct {
@@ -26,12 +32,12 @@ func newJs(c client.WeChatClient) Js {
 }
 
 func (api *js) GetTicket() (*JSTicket, error) {
-	resp, err := api.c.Get("/cgi-bin/ticket/getticket?type=wx_card", true)
-	if err != nil {
-		return nil, err
-	}
-	ticket := &JSTicket{}
-	err = client.GetJson(resp, ticket)
+	return api.GetTicketContext(context.Background())
+}
+
+func (api *js) GetTicketContext(ctx context.Context) (*JSTicket, error) {
+	query := url.Values{"type": {"wx_card"}}
+	ticket, err := client.GetJSON[JSTicket](ctx, api.c, "/cgi-bin/ticket/getticket", query)
 	if err != nil {
 		return nil, err
 	}
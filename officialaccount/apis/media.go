@@ -0,0 +1,119 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/Xavier-Lam/go-wechat/client"
+)
+
+const (
+	MediaTypeImage = "image"
+	MediaTypeVoice = "voice"
+	MediaTypeVideo = "video"
+	MediaTypeThumb = "thumb"
+)
+
+type UploadedMedia struct {
+	Type      string `json:"type"`
+	MediaId   string `json:"media_id"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type UploadedImage struct {
+	Url string `json:"url"`
+}
+
+type MaterialDescription struct {
+	Title        string `json:"title"`
+	Introduction string `json:"introduction"`
+}
+
+type Material struct {
+	MediaId string `json:"media_id"`
+	Url     string `json:"url"`
+}
+
+type media struct {
+	c client.WeChatClient
+}
+
+// Media management
+// https://developers.weixin.qq.com/doc/offiaccount/Asset_Management/New_temporary_materials.html
+type Media interface {
+	// Upload a temporary media file
+	// https://developers.weixin.qq.com/doc/offiaccount/Asset_Management/New_temporary_materials.html
+	Upload(mediaType string, file *client.MultipartFile) (*UploadedMedia, error)
+
+	// Upload a temporary media file with the given context
+	UploadContext(ctx context.Context, mediaType string, file *client.MultipartFile) (*UploadedMedia, error)
+
+	// Download a temporary media file, the returned stream must be closed after reading
+	// https://developers.weixin.qq.com/doc/offiaccount/Asset_Management/Get_temporary_materials.html
+	Get(mediaId string) (*client.Stream, error)
+
+	// Download a temporary media file with the given context, the returned stream must be closed after reading
+	GetContext(ctx context.Context, mediaId string) (*client.Stream, error)
+
+	// Upload an image used in the content of an article
+	// https://developers.weixin.qq.com/doc/offiaccount/Asset_Management/Adding_Permanent_Assets.html
+	UploadImage(file *client.MultipartFile) (*UploadedImage, error)
+
+	// Upload an image used in the content of an article with the given context
+	UploadImageContext(ctx context.Context, file *client.MultipartFile) (*UploadedImage, error)
+
+	// Add a permanent material, the description is required for videos only
+	// https://developers.weixin.qq.com/doc/offiaccount/Asset_Management/Adding_Permanent_Assets.html
+	AddMaterial(mediaType string, file *client.MultipartFile, description *MaterialDescription) (*Material, error)
+
+	// Add a permanent material with the given context, the description is required for videos only
+	AddMaterialContext(ctx context.Context, mediaType string, file *client.MultipartFile, description *MaterialDescription) (*Material, error)
+}
+
+func newMedia(c client.WeChatClient) Media {
+	return &media{c: c}
+}
+
+func (api *media) Upload(mediaType string, file *client.MultipartFile) (*UploadedMedia, error) {
+	return api.UploadContext(context.Background(), mediaType, file)
+}
+
+func (api *media) UploadContext(ctx context.Context, mediaType string, file *client.MultipartFile) (*UploadedMedia, error) {
+	query := url.Values{"type": {mediaType}}
+	return client.PostMultipartJSON[UploadedMedia](ctx, api.c, "/cgi-bin/media/upload", query, file, nil)
+}
+
+func (api *media) Get(mediaId string) (*client.Stream, error) {
+	return api.GetContext(context.Background(), mediaId)
+}
+
+func (api *media) GetContext(ctx context.Context, mediaId string) (*client.Stream, error) {
+	query := url.Values{"media_id": {mediaId}}
+	return client.GetStream(ctx, api.c, "/cgi-bin/media/get", query)
+}
+
+func (api *media) UploadImage(file *client.MultipartFile) (*UploadedImage, error) {
+	return api.UploadImageContext(context.Background(), file)
+}
+
+func (api *media) UploadImageContext(ctx context.Context, file *client.MultipartFile) (*UploadedImage, error) {
+	return client.PostMultipartJSON[UploadedImage](ctx, api.c, "/cgi-bin/media/uploadimg", nil, file, nil)
+}
+
+func (api *media) AddMaterial(mediaType string, file *client.MultipartFile, description *MaterialDescription) (*Material, error) {
+	return api.AddMaterialContext(context.Background(), mediaType, file, description)
+}
+
+func (api *media) AddMaterialContext(ctx context.Context, mediaType string, file *client.MultipartFile, description *MaterialDescription) (*Material, error) {
+	query := url.Values{"type": {mediaType}}
+	var fields map[string]string
+	if description != nil {
+		data, err := json.Marshal(description)
+		if err != nil {
+			return nil, err
+		}
+		fields = map[string]string{"description": string(data)}
+	}
+	return client.PostMultipartJSON[Material](ctx, api.c, "/cgi-bin/material/add_material", query, file, fields)
+}
@@ -0,0 +1,97 @@
+package apis_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat/client"
+	"github.com/Xavier-Lam/go-wechat/internal/test"
+	"github.com/Xavier-Lam/go-wechat/officialaccount/apis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMediaGet(t *testing.T) {
+	mediaId := "mock-media-id"
+	content := "\xff\xd8\xff\xe0binary"
+
+	app := newMockOfficialAccount(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, "GET", req.Method)
+		test.AssertEndpointEqual(t, "https://api.weixin.qq.com/cgi-bin/media/get", req.URL)
+		assert.Equal(t, accessToken, req.URL.Query().Get("access_token"))
+		assert.Equal(t, mediaId, req.URL.Query().Get("media_id"))
+
+		recorder := httptest.NewRecorder()
+		if calls == 2 {
+			recorder.Header().Set("Content-Type", "text/plain")
+			recorder.WriteString(`{"errcode":40007,"errmsg":"invalid media_id"}`)
+			return recorder.Result(), nil
+		}
+		recorder.Header().Set("Content-Type", "image/jpeg")
+		recorder.Header().Set("Content-Disposition", `attachment; filename="MEDIA_ID.jpg"`)
+		recorder.WriteString(content)
+		return recorder.Result(), nil
+	})
+
+	stream, err := app.Apis.Media.Get(mediaId)
+	assert.NoError(t, err)
+	defer stream.Close()
+	assert.Equal(t, "image/jpeg", stream.ContentType)
+	assert.Equal(t, "MEDIA_ID.jpg", stream.Filename)
+	data, err := io.ReadAll(stream)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(data))
+
+	_, err = app.Apis.Media.Get(mediaId)
+	var apiError client.WeChatApiError
+	assert.True(t, errors.As(err, &apiError))
+	assert.Equal(t, 40007, apiError.ErrCode)
+}
+
+func TestMediaUpload(t *testing.T) {
+	app := newMockOfficialAccount(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, accessToken, req.URL.Query().Get("access_token"))
+		assert.NoError(t, req.ParseMultipartForm(1024))
+		file, header, err := req.FormFile(client.MultipartFileField)
+		assert.NoError(t, err)
+		defer file.Close()
+		content, _ := io.ReadAll(file)
+		assert.Equal(t, "content", string(content))
+		assert.Equal(t, "file.mp4", header.Filename)
+
+		switch req.URL.Path {
+		case "/cgi-bin/media/upload":
+			assert.Equal(t, apis.MediaTypeImage, req.URL.Query().Get("type"))
+			return test.Responses.Json(`{"type":"image","media_id":"MEDIA_ID","created_at":123456789}`)
+		case "/cgi-bin/media/uploadimg":
+			return test.Responses.Json(`{"url":"http://mmbiz.qpic.cn/image.jpg"}`)
+		default:
+			test.AssertEndpointEqual(t, "https://api.weixin.qq.com/cgi-bin/material/add_material", req.URL)
+			assert.Equal(t, apis.MediaTypeVideo, req.URL.Query().Get("type"))
+			assert.JSONEq(t, `{"title":"title","introduction":"introduction"}`, req.FormValue("description"))
+			return test.Responses.Json(`{"media_id":"MEDIA_ID","url":"URL"}`)
+		}
+	})
+	newFile := func() *client.MultipartFile {
+		return &client.MultipartFile{Reader: strings.NewReader("content"), Filename: "file.mp4"}
+	}
+
+	uploaded, err := app.Apis.Media.Upload(apis.MediaTypeImage, newFile())
+	assert.NoError(t, err)
+	assert.Equal(t, &apis.UploadedMedia{Type: "image", MediaId: "MEDIA_ID", CreatedAt: 123456789}, uploaded)
+
+	image, err := app.Apis.Media.UploadImage(newFile())
+	assert.NoError(t, err)
+	assert.Equal(t, "http://mmbiz.qpic.cn/image.jpg", image.Url)
+
+	material, err := app.Apis.Media.AddMaterial(apis.MediaTypeVideo, newFile(), &apis.MaterialDescription{
+		Title:        "title",
+		Introduction: "introduction",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &apis.Material{MediaId: "MEDIA_ID", Url: "URL"}, material)
+}
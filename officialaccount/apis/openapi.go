@@ -0,0 +1,117 @@
+package apis
+
+import (
+	"context"
+
+	"github.com/Xavier-Lam/go-wechat/client"
+)
+
+type Quota struct {
+	DailyLimit int `json:"daily_limit"`
+	Used       int `json:"used"`
+	Remain     int `json:"remain"`
+}
+
+type RateLimit struct {
+	CallCount     int `json:"call_count"`
+	RefreshSecond int `json:"refresh_second"`
+}
+
+type QuotaInfo struct {
+	Quota              Quota     `json:"quota"`
+	RateLimit          RateLimit `json:"rate_limit"`
+	ComponentRateLimit RateLimit `json:"component_rate_limit"`
+}
+
+type RidRequest struct {
+	InvokeTime   int64  `json:"invoke_time"`
+	CostInMs     int    `json:"cost_in_ms"`
+	RequestUrl   string `json:"request_url"`
+	RequestBody  string `json:"request_body"`
+	ResponseBody string `json:"response_body"`
+	ClientIp     string `json:"client_ip"`
+}
+
+type RidInfo struct {
+	Request RidRequest `json:"request"`
+}
+
+type ApiDomainIp struct {
+	IpList []string `json:"ip_list"`
+}
+
+type openapi struct {
+	c client.WeChatClient
+}
+
+// Openapi management
+// https://developers.weixin.qq.com/doc/offiaccount/openApi/get_api_quota.html
+type Openapi interface {
+	// Query the daily quota of an API
+	// https://developers.weixin.qq.com/doc/offiaccount/openApi/get_api_quota.html
+	GetQuota(cgiPath string) (*QuotaInfo, error)
+
+	// Query the daily quota of an API with the given context
+	GetQuotaContext(ctx context.Context, cgiPath string) (*QuotaInfo, error)
+
+	// Reset the daily quotas of all APIs
+	// https://developers.weixin.qq.com/doc/offiaccount/openApi/clear_quota.html
+	ClearQuota() error
+
+	// Reset the daily quotas of all APIs with the given context
+	ClearQuotaContext(ctx context.Context) error
+
+	// Query the details of a request by the rid of its error
+	// https://developers.weixin.qq.com/doc/offiaccount/openApi/get_rid_info.html
+	GetRid(rid string) (*RidInfo, error)
+
+	// Query the details of a request by the rid of its error with the given context
+	GetRidContext(ctx context.Context, rid string) (*RidInfo, error)
+
+	// Get the IP addresses of WeChat API servers
+	// https://developers.weixin.qq.com/doc/offiaccount/Basic_Information/Get_the_WeChat_server_IP_address.html
+	GetApiDomainIp() (*ApiDomainIp, error)
+
+	// Get the IP addresses of WeChat API servers with the given context
+	GetApiDomainIpContext(ctx context.Context) (*ApiDomainIp, error)
+}
+
+func newOpenapi(c client.WeChatClient) Openapi {
+	return &openapi{c: c}
+}
+
+func (api *openapi) GetQuota(cgiPath string) (*QuotaInfo, error) {
+	return api.GetQuotaContext(context.Background(), cgiPath)
+}
+
+func (api *openapi) GetQuotaContext(ctx context.Context, cgiPath string) (*QuotaInfo, error) {
+	data := map[string]string{"cgi_path": cgiPath}
+	return client.PostJSON[map[string]string, QuotaInfo](ctx, api.c, "/cgi-bin/openapi/quota/get", data)
+}
+
+func (api *openapi) ClearQuota() error {
+	return api.ClearQuotaContext(context.Background())
+}
+
+func (api *openapi) ClearQuotaContext(ctx context.Context) error {
+	data := map[string]string{"appid": api.c.GetAuth().GetAppId()}
+	_, err := client.PostJSON[map[string]string, struct{}](ctx, api.c, "/cgi-bin/clear_quota", data)
+	return err
+}
+
+func (api *openapi) GetRid(rid string) (*RidInfo, error) {
+	return api.GetRidContext(context.Background(), rid)
+}
+
+func (api *openapi) GetRidContext(ctx context.Context, rid string) (*RidInfo, error) {
+	data := map[string]string{"rid": rid}
+	return client.PostJSON[map[string]string, RidInfo](ctx, api.c, "/cgi-bin/openapi/rid/get", data)
+}
+
+func (api *openapi) GetApiDomainIp() (*ApiDomainIp, error) {
+	return api.GetApiDomainIpContext(context.Background())
+}
+
+func (api *openapi) GetApiDomainIpContext(ctx context.Context) (*ApiDomainIp, error) {
+	return client.GetJSON[ApiDomainIp](ctx, api.c, "/cgi-bin/get_api_domain_ip", nil)
+}
@@ -0,0 +1,117 @@
+package apis_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenapiGetQuota(t *testing.T) {
+	data := `{
+		"errcode": 0,
+		"errmsg": "ok",
+		"quota": {
+			"daily_limit": 10000000,
+			"used": 500,
+			"remain": 9999500
+		},
+		"rate_limit": {
+			"call_count": 3000,
+			"refresh_second": 60
+		},
+		"component_rate_limit": {
+			"call_count": 200,
+			"refresh_second": 60
+		}
+	}`
+
+	app := newMockOfficialAccount(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, "POST", req.Method)
+		test.AssertEndpointEqual(t, "https://api.weixin.qq.com/cgi-bin/openapi/quota/get", req.URL)
+		assert.Equal(t, accessToken, req.URL.Query().Get("access_token"))
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.JSONEq(t, `{"cgi_path": "/cgi-bin/message/custom/send"}`, string(body))
+
+		return test.Responses.Json(data)
+	})
+
+	quota, err := app.Apis.Openapi.GetQuota("/cgi-bin/message/custom/send")
+	assert.NoError(t, err)
+	assert.Equal(t, 10000000, quota.Quota.DailyLimit)
+	assert.Equal(t, 500, quota.Quota.Used)
+	assert.Equal(t, 9999500, quota.Quota.Remain)
+	assert.Equal(t, 3000, quota.RateLimit.CallCount)
+	assert.Equal(t, 60, quota.RateLimit.RefreshSecond)
+	assert.Equal(t, 200, quota.ComponentRateLimit.CallCount)
+	assert.Equal(t, 60, quota.ComponentRateLimit.RefreshSecond)
+}
+
+func TestOpenapiClearQuota(t *testing.T) {
+	app := newMockOfficialAccount(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, "POST", req.Method)
+		test.AssertEndpointEqual(t, "https://api.weixin.qq.com/cgi-bin/clear_quota", req.URL)
+		assert.Equal(t, accessToken, req.URL.Query().Get("access_token"))
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.JSONEq(t, `{"appid": "`+appID+`"}`, string(body))
+
+		return test.Responses.Json(`{"errcode": 0, "errmsg": "ok"}`)
+	})
+
+	err := app.Apis.Openapi.ClearQuota()
+	assert.NoError(t, err)
+}
+
+func TestOpenapiGetRid(t *testing.T) {
+	data := `{
+		"errcode": 0,
+		"errmsg": "ok",
+		"request": {
+			"invoke_time": 1635156704,
+			"cost_in_ms": 30,
+			"request_url": "access_token=50_Im7xxxx",
+			"request_body": "",
+			"response_body": "{\"errcode\":45009,\"errmsg\":\"reach max api daily quota limit rid: 617682e0-09059ac5-34a8e2ea\"}",
+			"client_ip": "113.xx.70.51"
+		}
+	}`
+
+	app := newMockOfficialAccount(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, "POST", req.Method)
+		test.AssertEndpointEqual(t, "https://api.weixin.qq.com/cgi-bin/openapi/rid/get", req.URL)
+		assert.Equal(t, accessToken, req.URL.Query().Get("access_token"))
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.JSONEq(t, `{"rid": "617682e0-09059ac5-34a8e2ea"}`, string(body))
+
+		return test.Responses.Json(data)
+	})
+
+	info, err := app.Apis.Openapi.GetRid("617682e0-09059ac5-34a8e2ea")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1635156704), info.Request.InvokeTime)
+	assert.Equal(t, 30, info.Request.CostInMs)
+	assert.Equal(t, "access_token=50_Im7xxxx", info.Request.RequestUrl)
+	assert.Equal(t, "", info.Request.RequestBody)
+	assert.Contains(t, info.Request.ResponseBody, "45009")
+	assert.Equal(t, "113.xx.70.51", info.Request.ClientIp)
+}
+
+func TestOpenapiGetApiDomainIp(t *testing.T) {
+	app := newMockOfficialAccount(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, "GET", req.Method)
+		test.AssertEndpointEqual(t, "https://api.weixin.qq.com/cgi-bin/get_api_domain_ip", req.URL)
+		assert.Equal(t, accessToken, req.URL.Query().Get("access_token"))
+
+		return test.Responses.Json(`{"ip_list": ["101.226.62.77", "101.226.62.78"]}`)
+	})
+
+	ips, err := app.Apis.Openapi.GetApiDomainIp()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"101.226.62.77", "101.226.62.78"}, ips.IpList)
+}
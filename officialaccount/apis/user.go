@@ -1,6 +1,7 @@
 package apis
 
 import (
+	"context"
 	"net/url"
 
 	"github.com/Xavier-Lam/go-wechat/client"
@@ -30,6 +31,9 @@ type User interface {
 	// Obtaining Users' Basic Information
 	// https://developers.weixin.qq.com/doc/offiaccount/User_Management/Get_users_basic_information_UnionID.html#UinonId
 	GetInfo(openid string, lang string) (*UserInfo, error)
+
+	// Obtaining Users' Basic Information with the given context
+	GetInfoContext(ctx context.Context, openid string, lang string) (*UserInfo, error)
 }
 
 func newUser(c client.WeChatClient) User {
@@ -37,19 +41,13 @@ func newUser(c client.WeChatClient) User {
 }
 
 func (api *user) GetInfo(openid string, lang string) (*UserInfo, error) {
-	q := url.Values{}
-	q.Add("openid", openid)
-	q.Add("lang", lang)
-	// TODO: build query string
-	endpoint := "/cgi-bin/user/info"
-	resp, err := api.c.Get(endpoint+"?"+q.Encode(), true)
-	if err != nil {
-		return nil, err
-	}
-	userInfo := &UserInfo{}
-	err = client.GetJson(resp, userInfo)
-	if err != nil {
-		return nil, err
+	return api.GetInfoContext(context.Background(), openid, lang)
+}
+
+func (api *user) GetInfoContext(ctx context.Context, openid string, lang string) (*UserInfo, error) {
+	query := url.Values{
+		"openid": {openid},
+		"lang":   {lang},
 	}
-	return userInfo, nil
+	return client.GetJSON[UserInfo](ctx, api.c, "/cgi-bin/user/info", query)
 }
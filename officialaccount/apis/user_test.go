@@ -1,6 +1,7 @@
 package apis_test
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -50,3 +51,22 @@ func TestUserGetInfo(t *testing.T) {
 	assert.Equal(t, 98765, userInfo.QrScene)
 	assert.Equal(t, "", userInfo.QrSceneStr)
 }
+
+type ctxKey struct{}
+
+func TestUserGetInfoContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	app := newMockOfficialAccount(func(req *http.Request, calls int) (*http.Response, error) {
+		assert.Equal(t, "value", req.Context().Value(ctxKey{}))
+		return test.Responses.Json(`{"openid": "openid"}`)
+	})
+
+	userInfo, err := app.Apis.User.GetInfoContext(ctx, "openid", "zh_CN")
+	assert.NoError(t, err)
+	assert.Equal(t, "openid", userInfo.OpenId)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = app.Apis.User.GetInfoContext(ctx, "openid", "zh_CN")
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -1,16 +1,22 @@
 package officialaccount
 
 import (
+	"context"
 	"crypto/sha1"
+	"encoding/json"
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/Xavier-Lam/go-wechat"
 	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/Xavier-Lam/go-wechat/internal/singleflight"
 	"github.com/Xavier-Lam/go-wechat/officialaccount/apis"
 )
 
+const jsTicketLockTTL = 10
+
 func init() {
 	rand.Seed(time.Now().UnixNano())
 }
@@ -25,16 +31,39 @@ type JsConfig struct {
 }
 
 type js struct {
-	api   apis.Js
-	auth  wechat.Auth
-	cache caches.Cache
+	api    apis.Js
+	auth   wechat.Auth
+	cache  caches.Cache
+	group  *singleflight.Group
+	expiry *ticketExpiry
+}
+
+// The expiry of the last ticket fetched by this process, used if there is no `Cache` set up
+type ticketExpiry struct {
+	mu        sync.Mutex
+	expiresAt time.Time
+	lifetime  time.Duration
+}
+
+// A ticket stored in cache along with its expiry
+type cachedTicket struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresIn int       `json:"expires_in"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (t *cachedTicket) expiry() (time.Time, time.Duration) {
+	lifetime := time.Duration(t.ExpiresIn) * time.Second
+	return t.CreatedAt.Add(lifetime), lifetime
 }
 
 func newJs(auth wechat.Auth, api apis.Js, cache caches.Cache) *js {
 	return &js{
-		api:   api,
-		auth:  auth,
-		cache: cache,
+		api:    api,
+		auth:   auth,
+		cache:  cache,
+		group:  &singleflight.Group{},
+		expiry: &ticketExpiry{},
 	}
 }
 
@@ -42,34 +71,62 @@ func newJs(auth wechat.Auth, api apis.Js, cache caches.Cache) *js {
 // It may return an error along with the ticket if there is no `Cache` set up.
 // https://developers.weixin.qq.com/doc/offiaccount/OA_Web_Apps/JS-SDK.html#54
 func (j *js) GetTicket() (string, error) {
-	if j.cache != nil {
-		cachedValue, err := j.cache.Get(j.auth.GetAppId(), caches.BizJSTicket)
-		if err == nil {
-			if ticket := string(cachedValue); ticket != "" {
-				return ticket, nil
-			}
-		}
+	return j.GetTicketContext(context.Background())
+}
+
+// Get the latest validate ticket with the given context, see `GetTicket`
+func (j *js) GetTicketContext(ctx context.Context) (string, error) {
+	if ticket := j.cachedTicket(false); ticket != nil {
+		return ticket.Ticket, nil
 	}
 
-	return j.FetchTicket()
+	return j.FetchTicketContext(ctx)
 }
 
 // Obtaining api_ticket from server side
+// Concurrent fetching is deduplicated, the other callers wait for the result.
 // It may return an error along with the ticket if there is no `Cache` set up.
 // https://developers.weixin.qq.com/doc/offiaccount/OA_Web_Apps/JS-SDK.html#54
 func (j *js) FetchTicket() (string, error) {
-	ticket, err := j.api.GetTicket()
+	return j.FetchTicketContext(context.Background())
+}
+
+// Obtaining api_ticket from server side with the given context, see `FetchTicket`
+func (j *js) FetchTicketContext(ctx context.Context) (string, error) {
+	rv, err := j.group.Do(ctx, j.auth.GetAppId(), func(ctx context.Context) (interface{}, error) {
+		return j.fetchTicket(ctx)
+	})
+	ticket, _ := rv.(string)
+	return ticket, err
+}
+
+func (j *js) fetchTicket(ctx context.Context) (string, error) {
+	ticket, err := j.api.GetTicketContext(ctx)
 	if err != nil {
 		return "", err
 	}
 
+	lifetime := time.Duration(ticket.ExpiresIn) * time.Second
+	j.expiry.mu.Lock()
+	j.expiry.expiresAt = time.Now().Add(lifetime)
+	j.expiry.lifetime = lifetime
+	j.expiry.mu.Unlock()
+
 	if j.cache == nil {
 		err = fmt.Errorf("cache is not set")
 	} else {
+		data, err := json.Marshal(&cachedTicket{
+			Ticket:    ticket.Ticket,
+			ExpiresIn: ticket.ExpiresIn,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			return "", err
+		}
 		err = j.cache.Set(
 			j.auth.GetAppId(),
 			caches.BizJSTicket,
-			[]byte(ticket.Ticket),
+			data,
 			ticket.ExpiresIn,
 		)
 	}
@@ -77,6 +134,76 @@ func (j *js) FetchTicket() (string, error) {
 	return ticket.Ticket, err
 }
 
+// Reads the ticket in cache without fetching, nil is returned if it is missing, expired or there is no `Cache` set up.
+// The local copy kept by the cache is dropped first if `shared` is set, so that the ticket stored by other processes is seen.
+func (j *js) cachedTicket(shared bool) *cachedTicket {
+	if j.cache == nil {
+		return nil
+	}
+	if invalidator, ok := j.cache.(caches.Invalidator); ok && shared {
+		invalidator.Invalidate(j.auth.GetAppId(), caches.BizJSTicket)
+	}
+	data, err := j.cache.Get(j.auth.GetAppId(), caches.BizJSTicket)
+	if err != nil {
+		return nil
+	}
+	ticket := &cachedTicket{}
+	if err := json.Unmarshal(data, ticket); err != nil || ticket.Ticket == "" {
+		return nil
+	}
+	// A local copy may outlive the shared value
+	if expiresAt, _ := ticket.expiry(); !time.Now().Before(expiresAt) {
+		if invalidator, ok := j.cache.(caches.Invalidator); ok {
+			invalidator.Invalidate(j.auth.GetAppId(), caches.BizJSTicket)
+		}
+		return nil
+	}
+	return ticket
+}
+
+// Expiry implements `client.Refreshable`.
+// The expiry is read from the `Cache`, or is the one of the ticket last fetched
+// by this process if there is no `Cache` set up.
+func (j *js) Expiry(ctx context.Context) (time.Time, time.Duration) {
+	if j.cache != nil {
+		if ticket := j.cachedTicket(true); ticket != nil {
+			return ticket.expiry()
+		}
+		return time.Time{}, 0
+	}
+
+	j.expiry.mu.Lock()
+	defer j.expiry.mu.Unlock()
+	return j.expiry.expiresAt, j.expiry.lifetime
+}
+
+// Refresh implements `client.Refreshable`.
+// The processes sharing the `Cache` take a lock in it, only the holder renews the ticket.
+func (j *js) Refresh(ctx context.Context) error {
+	if j.cache == nil {
+		_, err := j.FetchTicketContext(ctx)
+		return err
+	}
+
+	stale := j.cachedTicket(true)
+	value := []byte(getRandomString(16))
+	err := j.cache.Add(j.auth.GetAppId(), caches.BizJSTicketLock, value, jsTicketLockTTL)
+	if err == caches.ErrKeyExisted {
+		// Being renewed by another process
+		return nil
+	} else if err == nil {
+		defer j.cache.Delete(j.auth.GetAppId(), caches.BizJSTicketLock, value)
+		// The previous holder may have stored a ticket right before releasing the lock
+		if ticket := j.cachedTicket(true); ticket != nil && (stale == nil || *ticket != *stale) {
+			return nil
+		}
+	}
+	// The lock is best-effort, do not fail the refreshing for a faulty cache
+
+	_, err = j.FetchTicketContext(ctx)
+	return err
+}
+
 func (j *js) GetJsConfig(url string, c JsConfig) (JsConfig, error) {
 	var err error
 	c.AppId = j.auth.GetAppId()
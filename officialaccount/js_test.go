@@ -1,11 +1,16 @@
 package officialaccount_test
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/Xavier-Lam/go-wechat"
 	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/Xavier-Lam/go-wechat/client"
 	"github.com/Xavier-Lam/go-wechat/officialaccount"
 	"github.com/Xavier-Lam/go-wechat/officialaccount/apis"
 	"github.com/stretchr/testify/assert"
@@ -22,6 +27,10 @@ func newMockJsApi(ticket string) apis.Js {
 }
 
 func (api *mockJsApi) GetTicket() (*apis.JSTicket, error) {
+	return api.GetTicketContext(context.Background())
+}
+
+func (api *mockJsApi) GetTicketContext(ctx context.Context) (*apis.JSTicket, error) {
 	return &apis.JSTicket{
 		Ticket:    api.ticket,
 		ExpiresIn: 7200,
@@ -120,3 +129,102 @@ func TestJsSign(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, actualSignature, signature)
 }
+
+type slowJsApi struct {
+	calls int32
+}
+
+func (api *slowJsApi) GetTicket() (*apis.JSTicket, error) {
+	return api.GetTicketContext(context.Background())
+}
+
+func (api *slowJsApi) GetTicketContext(ctx context.Context) (*apis.JSTicket, error) {
+	atomic.AddInt32(&api.calls, 1)
+	time.Sleep(50 * time.Millisecond)
+	return &apis.JSTicket{
+		Ticket:    "ticket",
+		ExpiresIn: 7200,
+	}, nil
+}
+
+func TestJsFetchTicketConcurrently(t *testing.T) {
+	auth := wechat.NewAuth("app-id", "app-secret")
+	api := &slowJsApi{}
+	js := officialaccount.NewJs(auth, api, caches.NewDummyCache())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticket, err := js.FetchTicket()
+			assert.NoError(t, err)
+			assert.Equal(t, "ticket", ticket)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&api.calls))
+}
+
+func TestJsRefreshable(t *testing.T) {
+	auth := wechat.NewAuth("app-id", "app-secret")
+	api := &slowJsApi{}
+	js := officialaccount.NewJs(auth, api, caches.NewDummyCache())
+	var r client.Refreshable = js
+
+	expiresAt, _ := r.Expiry(context.Background())
+	assert.True(t, expiresAt.IsZero())
+
+	err := r.Refresh(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&api.calls))
+
+	expiresAt, lifetime := r.Expiry(context.Background())
+	assert.WithinDuration(t, time.Now().Add(7200*time.Second), expiresAt, time.Second)
+	assert.Equal(t, 7200*time.Second, lifetime)
+}
+
+func TestJsRefreshableSharedCache(t *testing.T) {
+	auth := wechat.NewAuth("app-id", "app-secret")
+	cache := caches.NewDummyCache()
+	api := &slowJsApi{}
+	_, err := officialaccount.NewJs(auth, api, cache).FetchTicket()
+	assert.NoError(t, err)
+
+	// Another process reads the expiry of the ticket in cache
+	js := officialaccount.NewJs(auth, api, cache)
+	expiresAt, lifetime := js.Expiry(context.Background())
+	assert.WithinDuration(t, time.Now().Add(7200*time.Second), expiresAt, time.Second)
+	assert.Equal(t, 7200*time.Second, lifetime)
+
+	// The ticket is being renewed by another process
+	cache.Set("app-id", caches.BizJSTicketLock, []byte("other"), 10)
+	err = js.Refresh(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&api.calls))
+
+	cache.Delete("app-id", caches.BizJSTicketLock, nil)
+	err = js.Refresh(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&api.calls))
+
+	// lock released
+	_, err = cache.Get("app-id", caches.BizJSTicketLock)
+	assert.ErrorIs(t, err, caches.ErrKeyNotFound)
+}
+
+func TestJsExpiredLocalTicket(t *testing.T) {
+	auth := wechat.NewAuth("app-id", "app-secret")
+	cache := caches.NewDummyCache()
+	expired := fmt.Sprintf(
+		`{"ticket": "expired", "expires_in": 7200, "created_at": %q}`,
+		time.Now().Add(-3*time.Hour).Format(time.RFC3339),
+	)
+	cache.Set("app-id", caches.BizJSTicket, []byte(expired), 30)
+	js := officialaccount.NewJs(auth, newMockJsApi("ticket"), cache)
+
+	ticket, err := js.GetTicket()
+	assert.NoError(t, err)
+	assert.Equal(t, "ticket", ticket)
+}
@@ -0,0 +1,308 @@
+package messages
+
+import (
+	"encoding/xml"
+)
+
+const (
+	EventSubscribe             = "subscribe"
+	EventUnsubscribe           = "unsubscribe"
+	EventScan                  = "SCAN"
+	EventLocation              = "LOCATION"
+	EventClick                 = "CLICK"
+	EventView                  = "VIEW"
+	EventScancodePush          = "scancode_push"
+	EventScancodeWaitMsg       = "scancode_waitmsg"
+	EventPicSysPhoto           = "pic_sysphoto"
+	EventPicPhotoOrAlbum       = "pic_photo_or_album"
+	EventPicWeixin             = "pic_weixin"
+	EventLocationSelect        = "location_select"
+	EventViewMiniProgram       = "view_miniprogram"
+	EventTemplateSendJobFinish = "TEMPLATESENDJOBFINISH"
+	EventMassSendJobFinish     = "MASSSENDJOBFINISH"
+	EventSubscribeMsgPopup     = "subscribe_msg_popup_event"
+	EventSubscribeMsgChange    = "subscribe_msg_change_event"
+	EventSubscribeMsgSent      = "subscribe_msg_sent_event"
+)
+
+// EventHeader holds the fields shared by all events
+type EventHeader struct {
+	Header
+	Event string `xml:"Event"`
+}
+
+func (h *EventHeader) GetEvent() string {
+	return h.Event
+}
+
+// EventMessage is an inbound event
+// https://developers.weixin.qq.com/doc/offiaccount/Message_Management/Receiving_event_pushes.html
+type EventMessage interface {
+	Message
+	GetEvent() string
+}
+
+// KeyedEvent is an event carrying an `EventKey`, such as a menu click or a QR code scan
+type KeyedEvent interface {
+	EventMessage
+	GetEventKey() string
+}
+
+// SubscribeEvent is sent when a user follows the account,
+// `EventKey` and `Ticket` are given if the user scanned a parametric QR code.
+type SubscribeEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+	EventKey string `xml:"EventKey,omitempty"` // The scene with a "qrscene_" prefix
+	Ticket   string `xml:"Ticket,omitempty"`
+}
+
+func (e *SubscribeEvent) GetEventKey() string {
+	return e.EventKey
+}
+
+type UnsubscribeEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+}
+
+// ScanEvent is sent when a follower scans a parametric QR code
+type ScanEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+	EventKey string `xml:"EventKey"` // The scene of the QR code
+	Ticket   string `xml:"Ticket"`
+}
+
+func (e *ScanEvent) GetEventKey() string {
+	return e.EventKey
+}
+
+// LocationEvent reports the location of a follower
+type LocationEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+	Latitude  float64 `xml:"Latitude"`
+	Longitude float64 `xml:"Longitude"`
+	Precision float64 `xml:"Precision"`
+}
+
+// ClickEvent is sent when a user clicks a menu item of type "click"
+type ClickEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+	EventKey string `xml:"EventKey"` // The key of the menu item
+}
+
+func (e *ClickEvent) GetEventKey() string {
+	return e.EventKey
+}
+
+// ViewEvent is sent when a user clicks a menu item of type "view"
+type ViewEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+	EventKey string `xml:"EventKey"` // The url of the menu item
+	MenuId   string `xml:"MenuId,omitempty"`
+}
+
+func (e *ViewEvent) GetEventKey() string {
+	return e.EventKey
+}
+
+type ScanCodeInfo struct {
+	ScanType   string `xml:"ScanType"`
+	ScanResult string `xml:"ScanResult"`
+}
+
+// ScancodeEvent is sent when a user scans a code by a menu item of type "scancode_push" or "scancode_waitmsg"
+type ScancodeEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+	EventKey     string       `xml:"EventKey"`
+	ScanCodeInfo ScanCodeInfo `xml:"ScanCodeInfo"`
+}
+
+func (e *ScancodeEvent) GetEventKey() string {
+	return e.EventKey
+}
+
+type PicItem struct {
+	PicMd5Sum string `xml:"PicMd5Sum"`
+}
+
+type SendPicsInfo struct {
+	Count   int       `xml:"Count"`
+	PicList []PicItem `xml:"PicList>item"`
+}
+
+// PicEvent is sent when a user sends pictures by a menu item of type "pic_sysphoto", "pic_photo_or_album" or "pic_weixin"
+type PicEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+	EventKey     string       `xml:"EventKey"`
+	SendPicsInfo SendPicsInfo `xml:"SendPicsInfo"`
+}
+
+func (e *PicEvent) GetEventKey() string {
+	return e.EventKey
+}
+
+type SendLocationInfo struct {
+	LocationX float64 `xml:"Location_X"`
+	LocationY float64 `xml:"Location_Y"`
+	Scale     int     `xml:"Scale"`
+	Label     string  `xml:"Label"`
+	Poiname   string  `xml:"Poiname"`
+}
+
+// LocationSelectEvent is sent when a user sends a location by a menu item of type "location_select"
+type LocationSelectEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+	EventKey         string           `xml:"EventKey"`
+	SendLocationInfo SendLocationInfo `xml:"SendLocationInfo"`
+}
+
+func (e *LocationSelectEvent) GetEventKey() string {
+	return e.EventKey
+}
+
+// ViewMiniProgramEvent is sent when a user clicks a menu item of type "miniprogram"
+type ViewMiniProgramEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+	EventKey string `xml:"EventKey"` // The page path of the mini program
+	MenuId   string `xml:"MenuId"`
+}
+
+func (e *ViewMiniProgramEvent) GetEventKey() string {
+	return e.EventKey
+}
+
+// TemplateSendJobFinishEvent reports the result of sending a template message
+type TemplateSendJobFinishEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+	MsgId  int64  `xml:"MsgID"`
+	Status string `xml:"Status"`
+}
+
+type CopyrightCheckItem struct {
+	ArticleIdx            int    `xml:"ArticleIdx"`
+	UserDeclareState      int    `xml:"UserDeclareState"`
+	AuditState            int    `xml:"AuditState"`
+	OriginalArticleUrl    string `xml:"OriginalArticleUrl"`
+	OriginalArticleType   int    `xml:"OriginalArticleType"`
+	CanReprint            int    `xml:"CanReprint"`
+	NeedReplaceContent    int    `xml:"NeedReplaceContent"`
+	NeedShowReprintSource int    `xml:"NeedShowReprintSource"`
+}
+
+type CopyrightCheckResult struct {
+	Count      int                  `xml:"Count"`
+	ResultList []CopyrightCheckItem `xml:"ResultList>item"`
+	CheckState int                  `xml:"CheckState"`
+}
+
+type ArticleUrlItem struct {
+	ArticleIdx int    `xml:"ArticleIdx"`
+	ArticleUrl string `xml:"ArticleUrl"`
+}
+
+type ArticleUrlResult struct {
+	Count      int              `xml:"Count"`
+	ResultList []ArticleUrlItem `xml:"ResultList>item"`
+}
+
+// MassSendJobFinishEvent reports the result of a mass message
+type MassSendJobFinishEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+	MsgId                int64                `xml:"MsgID"`
+	Status               string               `xml:"Status"`
+	TotalCount           int                  `xml:"TotalCount"`
+	FilterCount          int                  `xml:"FilterCount"`
+	SentCount            int                  `xml:"SentCount"`
+	ErrorCount           int                  `xml:"ErrorCount"`
+	CopyrightCheckResult CopyrightCheckResult `xml:"CopyrightCheckResult"`
+	ArticleUrlResult     ArticleUrlResult     `xml:"ArticleUrlResult"`
+}
+
+type SubscribeMsgPopupItem struct {
+	TemplateId            string `xml:"TemplateId"`
+	SubscribeStatusString string `xml:"SubscribeStatusString"` // "accept" or "reject"
+	PopupScene            int    `xml:"PopupScene"`
+}
+
+// SubscribeMsgPopupEvent is sent when a user responds to the subscription popup of a web page
+type SubscribeMsgPopupEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+	List []SubscribeMsgPopupItem `xml:"SubscribeMsgPopupEvent>List"`
+}
+
+type SubscribeMsgChangeItem struct {
+	TemplateId            string `xml:"TemplateId"`
+	SubscribeStatusString string `xml:"SubscribeStatusString"`
+}
+
+// SubscribeMsgChangeEvent is sent when a user changes the subscriptions in settings
+type SubscribeMsgChangeEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+	List []SubscribeMsgChangeItem `xml:"SubscribeMsgChangeEvent>List"`
+}
+
+type SubscribeMsgSentItem struct {
+	TemplateId  string `xml:"TemplateId"`
+	MsgId       string `xml:"MsgID"`
+	ErrorCode   int    `xml:"ErrorCode"`
+	ErrorStatus string `xml:"ErrorStatus"`
+}
+
+// SubscribeMsgSentEvent reports the result of sending a subscription message
+type SubscribeMsgSentEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+	List []SubscribeMsgSentItem `xml:"SubscribeMsgSentEvent>List"`
+}
+
+// UnknownEvent is an event of a type not supported yet, the raw XML is kept
+type UnknownEvent struct {
+	XMLName xml.Name `xml:"xml"`
+	EventHeader
+	Raw []byte `xml:"-"`
+}
+
+// Fields returns the text of the top level elements of the raw XML
+func (m *UnknownEvent) Fields() (map[string]string, error) {
+	return rawFields(m.Raw)
+}
+
+// MarshalXML writes the raw XML back
+func (m *UnknownEvent) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalRaw(e, m.Raw)
+}
+
+// The constructors of the events by `Event`
+var eventTypes = map[string]func() Message{
+	EventSubscribe:             func() Message { return &SubscribeEvent{} },
+	EventUnsubscribe:           func() Message { return &UnsubscribeEvent{} },
+	EventScan:                  func() Message { return &ScanEvent{} },
+	EventLocation:              func() Message { return &LocationEvent{} },
+	EventClick:                 func() Message { return &ClickEvent{} },
+	EventView:                  func() Message { return &ViewEvent{} },
+	EventScancodePush:          func() Message { return &ScancodeEvent{} },
+	EventScancodeWaitMsg:       func() Message { return &ScancodeEvent{} },
+	EventPicSysPhoto:           func() Message { return &PicEvent{} },
+	EventPicPhotoOrAlbum:       func() Message { return &PicEvent{} },
+	EventPicWeixin:             func() Message { return &PicEvent{} },
+	EventLocationSelect:        func() Message { return &LocationSelectEvent{} },
+	EventViewMiniProgram:       func() Message { return &ViewMiniProgramEvent{} },
+	EventTemplateSendJobFinish: func() Message { return &TemplateSendJobFinishEvent{} },
+	EventMassSendJobFinish:     func() Message { return &MassSendJobFinishEvent{} },
+	EventSubscribeMsgPopup:     func() Message { return &SubscribeMsgPopupEvent{} },
+	EventSubscribeMsgChange:    func() Message { return &SubscribeMsgChangeEvent{} },
+	EventSubscribeMsgSent:      func() Message { return &SubscribeMsgSentEvent{} },
+}
@@ -0,0 +1,180 @@
+package messages
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+)
+
+const (
+	MsgTypeText       = "text"
+	MsgTypeImage      = "image"
+	MsgTypeVoice      = "voice"
+	MsgTypeVideo      = "video"
+	MsgTypeShortVideo = "shortvideo"
+	MsgTypeLocation   = "location"
+	MsgTypeLink       = "link"
+	MsgTypeEvent      = "event"
+)
+
+// Header holds the fields shared by all inbound messages and events
+type Header struct {
+	ToUserName   string `xml:"ToUserName"`
+	FromUserName string `xml:"FromUserName"`
+	CreateTime   int64  `xml:"CreateTime"`
+	MsgType      string `xml:"MsgType"`
+}
+
+func (h *Header) GetHeader() *Header {
+	return h
+}
+
+// Message is an inbound message or event pushed to the server
+// https://developers.weixin.qq.com/doc/offiaccount/Message_Management/Receiving_standard_messages.html
+type Message interface {
+	GetHeader() *Header
+}
+
+type Text struct {
+	XMLName xml.Name `xml:"xml"`
+	Header
+	MsgId   int64  `xml:"MsgId"`
+	Content string `xml:"Content"`
+}
+
+type Image struct {
+	XMLName xml.Name `xml:"xml"`
+	Header
+	MsgId   int64  `xml:"MsgId"`
+	PicUrl  string `xml:"PicUrl"`
+	MediaId string `xml:"MediaId"`
+}
+
+type Voice struct {
+	XMLName xml.Name `xml:"xml"`
+	Header
+	MsgId       int64  `xml:"MsgId"`
+	MediaId     string `xml:"MediaId"`
+	MediaId16K  string `xml:"MediaId16K,omitempty"`
+	Format      string `xml:"Format"`
+	Recognition string `xml:"Recognition,omitempty"` // The result of speech recognition if it is enabled
+}
+
+// Video is a video or a short video message
+type Video struct {
+	XMLName xml.Name `xml:"xml"`
+	Header
+	MsgId        int64  `xml:"MsgId"`
+	MediaId      string `xml:"MediaId"`
+	ThumbMediaId string `xml:"ThumbMediaId"`
+}
+
+type Location struct {
+	XMLName xml.Name `xml:"xml"`
+	Header
+	MsgId     int64   `xml:"MsgId"`
+	LocationX float64 `xml:"Location_X"` // Latitude
+	LocationY float64 `xml:"Location_Y"` // Longitude
+	Scale     int     `xml:"Scale"`
+	Label     string  `xml:"Label"`
+}
+
+type Link struct {
+	XMLName xml.Name `xml:"xml"`
+	Header
+	MsgId       int64  `xml:"MsgId"`
+	Title       string `xml:"Title"`
+	Description string `xml:"Description"`
+	Url         string `xml:"Url"`
+}
+
+// Unknown is a message of a type not supported yet, the raw XML is kept
+type Unknown struct {
+	XMLName xml.Name `xml:"xml"`
+	Header
+	Raw []byte `xml:"-"`
+}
+
+// Fields returns the text of the top level elements of the raw XML
+func (m *Unknown) Fields() (map[string]string, error) {
+	return rawFields(m.Raw)
+}
+
+// MarshalXML writes the raw XML back
+func (m *Unknown) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalRaw(e, m.Raw)
+}
+
+// The constructors of the messages by `MsgType`
+var messageTypes = map[string]func() Message{
+	MsgTypeText:       func() Message { return &Text{} },
+	MsgTypeImage:      func() Message { return &Image{} },
+	MsgTypeVoice:      func() Message { return &Voice{} },
+	MsgTypeVideo:      func() Message { return &Video{} },
+	MsgTypeShortVideo: func() Message { return &Video{} },
+	MsgTypeLocation:   func() Message { return &Location{} },
+	MsgTypeLink:       func() Message { return &Link{} },
+}
+
+// Decode parses an inbound XML message into its concrete type by `MsgType`, or by `Event` for events.
+// An `*Unknown` or an `*UnknownEvent` is returned for unsupported types.
+func Decode(data []byte) (Message, error) {
+	header := &EventHeader{}
+	if err := xml.Unmarshal(data, header); err != nil {
+		return nil, err
+	}
+
+	var newMessage func() Message
+	var ok bool
+	if header.MsgType == MsgTypeEvent {
+		if newMessage, ok = eventTypes[header.Event]; !ok {
+			return &UnknownEvent{EventHeader: *header, Raw: data}, nil
+		}
+	} else if newMessage, ok = messageTypes[header.MsgType]; !ok {
+		return &Unknown{Header: header.Header, Raw: data}, nil
+	}
+
+	msg := newMessage()
+	if err := xml.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// Returns the text of the top level elements of the XML
+func rawFields(data []byte) (map[string]string, error) {
+	fields := &struct {
+		Elements []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	}{}
+	if err := xml.Unmarshal(data, fields); err != nil {
+		return nil, err
+	}
+	rv := make(map[string]string, len(fields.Elements))
+	for _, element := range fields.Elements {
+		rv[element.XMLName.Local] = element.Value
+	}
+	return rv, nil
+}
+
+// Copies the tokens of the XML to the encoder
+func marshalRaw(e *xml.Encoder, data []byte) error {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		switch token.(type) {
+		case xml.ProcInst, xml.Directive:
+			continue
+		}
+		if err := e.EncodeToken(token); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package messages_test
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat/officialaccount/messages"
+	"github.com/stretchr/testify/assert"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", name+".xml"))
+	assert.NoError(t, err)
+	return data
+}
+
+func decodeFixture(t *testing.T, name string) messages.Message {
+	msg, err := messages.Decode(readFixture(t, name))
+	assert.NoError(t, err)
+	return msg
+}
+
+func TestDecodeTypes(t *testing.T) {
+	cases := map[string]messages.Message{
+		"text":                             &messages.Text{},
+		"image":                            &messages.Image{},
+		"voice":                            &messages.Voice{},
+		"video":                            &messages.Video{},
+		"shortvideo":                       &messages.Video{},
+		"location":                         &messages.Location{},
+		"link":                             &messages.Link{},
+		"unknown":                          &messages.Unknown{},
+		"event_subscribe":                  &messages.SubscribeEvent{},
+		"event_unsubscribe":                &messages.UnsubscribeEvent{},
+		"event_scan":                       &messages.ScanEvent{},
+		"event_location":                   &messages.LocationEvent{},
+		"event_click":                      &messages.ClickEvent{},
+		"event_view":                       &messages.ViewEvent{},
+		"event_scancode_push":              &messages.ScancodeEvent{},
+		"event_pic_sysphoto":               &messages.PicEvent{},
+		"event_location_select":            &messages.LocationSelectEvent{},
+		"event_view_miniprogram":           &messages.ViewMiniProgramEvent{},
+		"event_templatesendjobfinish":      &messages.TemplateSendJobFinishEvent{},
+		"event_masssendjobfinish":          &messages.MassSendJobFinishEvent{},
+		"event_subscribe_msg_popup_event":  &messages.SubscribeMsgPopupEvent{},
+		"event_subscribe_msg_change_event": &messages.SubscribeMsgChangeEvent{},
+		"event_subscribe_msg_sent_event":   &messages.SubscribeMsgSentEvent{},
+		"event_unknown":                    &messages.UnknownEvent{},
+	}
+
+	for name, expected := range cases {
+		t.Run(name, func(t *testing.T) {
+			msg := decodeFixture(t, name)
+			assert.IsType(t, expected, msg)
+			assert.NotEmpty(t, msg.GetHeader().ToUserName)
+			assert.NotEmpty(t, msg.GetHeader().FromUserName)
+			assert.NotZero(t, msg.GetHeader().CreateTime)
+
+			event, isEvent := msg.(messages.EventMessage)
+			assert.Equal(t, strings.HasPrefix(name, "event_"), isEvent)
+			if isEvent {
+				assert.Equal(t, messages.MsgTypeEvent, msg.GetHeader().MsgType)
+				assert.NotEmpty(t, event.GetEvent())
+			}
+		})
+	}
+}
+
+func TestDecodeMessages(t *testing.T) {
+	assert.Equal(t, &messages.Text{
+		XMLName: xml.Name{Local: "xml"},
+		Header:  messages.Header{ToUserName: "toUser", FromUserName: "fromUser", CreateTime: 1348831860, MsgType: "text"},
+		MsgId:   1234567890123456,
+		Content: "this is a test",
+	}, decodeFixture(t, "text"))
+
+	voice := decodeFixture(t, "voice").(*messages.Voice)
+	assert.Equal(t, "media_id", voice.MediaId)
+	assert.Equal(t, "media_id_16k", voice.MediaId16K)
+	assert.Equal(t, "amr", voice.Format)
+	assert.Equal(t, "腾讯微信团队", voice.Recognition)
+
+	shortVideo := decodeFixture(t, "shortvideo").(*messages.Video)
+	assert.Equal(t, messages.MsgTypeShortVideo, shortVideo.MsgType)
+	assert.Equal(t, "thumb_media_id", shortVideo.ThumbMediaId)
+
+	location := decodeFixture(t, "location").(*messages.Location)
+	assert.Equal(t, 23.134521, location.LocationX)
+	assert.Equal(t, 113.358803, location.LocationY)
+	assert.Equal(t, 20, location.Scale)
+	assert.Equal(t, "位置信息", location.Label)
+
+	link := decodeFixture(t, "link").(*messages.Link)
+	assert.Equal(t, "公众平台官网链接", link.Title)
+	assert.Equal(t, "url", link.Url)
+}
+
+func TestDecodeEvents(t *testing.T) {
+	subscribe := decodeFixture(t, "event_subscribe").(*messages.SubscribeEvent)
+	assert.Equal(t, messages.EventSubscribe, subscribe.Event)
+	assert.Equal(t, "qrscene_123123", subscribe.EventKey)
+	assert.Equal(t, "TICKET", subscribe.Ticket)
+
+	scan := decodeFixture(t, "event_scan").(*messages.ScanEvent)
+	assert.Equal(t, "SCENE_VALUE", scan.EventKey)
+	assert.Equal(t, "TICKET", scan.Ticket)
+
+	location := decodeFixture(t, "event_location").(*messages.LocationEvent)
+	assert.Equal(t, 23.137466, location.Latitude)
+	assert.Equal(t, 113.352425, location.Longitude)
+	assert.Equal(t, 119.38504, location.Precision)
+
+	view := decodeFixture(t, "event_view").(*messages.ViewEvent)
+	assert.Equal(t, "www.qq.com", view.EventKey)
+	assert.Equal(t, "MENUID", view.MenuId)
+
+	scancode := decodeFixture(t, "event_scancode_push").(*messages.ScancodeEvent)
+	assert.Equal(t, messages.ScanCodeInfo{ScanType: "qrcode", ScanResult: "1"}, scancode.ScanCodeInfo)
+
+	pic := decodeFixture(t, "event_pic_sysphoto").(*messages.PicEvent)
+	assert.Equal(t, messages.SendPicsInfo{
+		Count:   1,
+		PicList: []messages.PicItem{{PicMd5Sum: "1b5f7c23b5bf75682a53e7b6d163e185"}},
+	}, pic.SendPicsInfo)
+
+	locationSelect := decodeFixture(t, "event_location_select").(*messages.LocationSelectEvent)
+	assert.Equal(t, messages.SendLocationInfo{
+		LocationX: 23,
+		LocationY: 113,
+		Scale:     15,
+		Label:     " 广州市海珠区客村艺苑路 106号",
+	}, locationSelect.SendLocationInfo)
+
+	template := decodeFixture(t, "event_templatesendjobfinish").(*messages.TemplateSendJobFinishEvent)
+	assert.Equal(t, int64(200163836), template.MsgId)
+	assert.Equal(t, "success", template.Status)
+
+	mass := decodeFixture(t, "event_masssendjobfinish").(*messages.MassSendJobFinishEvent)
+	assert.Equal(t, int64(1000001625), mass.MsgId)
+	assert.Equal(t, "err(30003)", mass.Status)
+	assert.Equal(t, 2, mass.CopyrightCheckResult.CheckState)
+	assert.Len(t, mass.CopyrightCheckResult.ResultList, 2)
+	assert.Equal(t, "Url_2", mass.CopyrightCheckResult.ResultList[1].OriginalArticleUrl)
+	assert.Equal(t, []messages.ArticleUrlItem{{ArticleIdx: 1, ArticleUrl: "Url"}}, mass.ArticleUrlResult.ResultList)
+
+	popup := decodeFixture(t, "event_subscribe_msg_popup_event").(*messages.SubscribeMsgPopupEvent)
+	assert.Equal(t, []messages.SubscribeMsgPopupItem{
+		{TemplateId: "VRR0UEO9VJOLs0MHlU0OilqX6MVFDwH3_3gz3Oc0NIc", SubscribeStatusString: "accept", PopupScene: 2},
+		{TemplateId: "9nLIlbOQZC5Y89AZteFEux3WCXRRRG5Wfzkpssu4bLI", SubscribeStatusString: "reject", PopupScene: 2},
+	}, popup.List)
+
+	sent := decodeFixture(t, "event_subscribe_msg_sent_event").(*messages.SubscribeMsgSentEvent)
+	assert.Equal(t, []messages.SubscribeMsgSentItem{{
+		TemplateId:  "VRR0UEO9VJOLs0MHlU0OilqX6MVFDwH3_3gz3Oc0NIc",
+		MsgId:       "1700827132819554304",
+		ErrorCode:   0,
+		ErrorStatus: "success",
+	}}, sent.List)
+}
+
+func TestDecodeUnknown(t *testing.T) {
+	data := readFixture(t, "unknown")
+	msg := decodeFixture(t, "unknown").(*messages.Unknown)
+	assert.Equal(t, "miniprogrampage", msg.MsgType)
+	assert.Equal(t, data, msg.Raw)
+	fields, err := msg.Fields()
+	assert.NoError(t, err)
+	assert.Equal(t, "pages/index/index", fields["PagePath"])
+
+	event := decodeFixture(t, "event_unknown").(*messages.UnknownEvent)
+	assert.Equal(t, "kf_create_session", event.GetEvent())
+	assert.Equal(t, "fromuser", event.FromUserName)
+	fields, err = event.Fields()
+	assert.NoError(t, err)
+	assert.Equal(t, "test1@test", fields["KfAccount"])
+
+	_, err = messages.Decode([]byte(`not xml`))
+	assert.Error(t, err)
+}
+
+func TestRoundTrip(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.xml"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, files)
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".xml")
+		t.Run(name, func(t *testing.T) {
+			msg := decodeFixture(t, name)
+			data, err := xml.Marshal(msg)
+			assert.NoError(t, err)
+			decoded, err := messages.Decode(data)
+			assert.NoError(t, err)
+
+			switch m := msg.(type) {
+			case *messages.Unknown:
+				assertSameFields(t, m.Fields, decoded.(*messages.Unknown).Fields)
+			case *messages.UnknownEvent:
+				assertSameFields(t, m.Fields, decoded.(*messages.UnknownEvent).Fields)
+			default:
+				assert.Equal(t, msg, decoded)
+			}
+		})
+	}
+}
+
+func assertSameFields(t *testing.T, expected, actual func() (map[string]string, error)) {
+	expectedFields, err := expected()
+	assert.NoError(t, err)
+	actualFields, err := actual()
+	assert.NoError(t, err)
+	assert.Equal(t, expectedFields, actualFields)
+}
@@ -0,0 +1,209 @@
+package messages
+
+import (
+	"encoding/xml"
+	"errors"
+	"time"
+)
+
+const (
+	ReplyTypeText                    = "text"
+	ReplyTypeImage                   = "image"
+	ReplyTypeVoice                   = "voice"
+	ReplyTypeVideo                   = "video"
+	ReplyTypeMusic                   = "music"
+	ReplyTypeNews                    = "news"
+	ReplyTypeTransferCustomerService = "transfer_customer_service"
+)
+
+// The max number of articles in a news reply
+const MaxNewsArticles = 8
+
+var ErrTooManyArticles = errors.New("too many articles in a news reply")
+
+// CDATA is a string marshalled into a CDATA section
+type CDATA string
+
+func (c CDATA) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(struct {
+		Text string `xml:",cdata"`
+	}{string(c)}, start)
+}
+
+// ReplyHeader holds the fields shared by all passive replies
+type ReplyHeader struct {
+	ToUserName   CDATA `xml:"ToUserName"`
+	FromUserName CDATA `xml:"FromUserName"`
+	CreateTime   int64 `xml:"CreateTime"`
+	MsgType      CDATA `xml:"MsgType"`
+}
+
+func (h *ReplyHeader) GetReplyHeader() *ReplyHeader {
+	return h
+}
+
+// Reply is a passive reply to an inbound message
+// https://developers.weixin.qq.com/doc/offiaccount/Message_Management/Passive_user_reply_message.html
+type Reply interface {
+	GetReplyHeader() *ReplyHeader
+}
+
+// Returns the header of a reply to the message
+func newReplyHeader(msg Message, replyType string) ReplyHeader {
+	header := msg.GetHeader()
+	return ReplyHeader{
+		ToUserName:   CDATA(header.FromUserName),
+		FromUserName: CDATA(header.ToUserName),
+		CreateTime:   time.Now().Unix(),
+		MsgType:      CDATA(replyType),
+	}
+}
+
+// EmptyReply acknowledges a message with "success" without answering the user
+type EmptyReply struct{}
+
+func (r *EmptyReply) GetReplyHeader() *ReplyHeader {
+	return nil
+}
+
+func NewEmptyReply() *EmptyReply {
+	return &EmptyReply{}
+}
+
+type TextReply struct {
+	XMLName xml.Name `xml:"xml"`
+	ReplyHeader
+	Content CDATA `xml:"Content"`
+}
+
+// NewTextReply creates a text reply to the message
+func NewTextReply(msg Message, content string) *TextReply {
+	return &TextReply{
+		ReplyHeader: newReplyHeader(msg, ReplyTypeText),
+		Content:     CDATA(content),
+	}
+}
+
+type ReplyMedia struct {
+	MediaId CDATA `xml:"MediaId"`
+}
+
+type ImageReply struct {
+	XMLName xml.Name `xml:"xml"`
+	ReplyHeader
+	Image ReplyMedia `xml:"Image"`
+}
+
+// NewImageReply creates an image reply to the message with an uploaded media
+func NewImageReply(msg Message, mediaId string) *ImageReply {
+	return &ImageReply{
+		ReplyHeader: newReplyHeader(msg, ReplyTypeImage),
+		Image:       ReplyMedia{MediaId: CDATA(mediaId)},
+	}
+}
+
+type VoiceReply struct {
+	XMLName xml.Name `xml:"xml"`
+	ReplyHeader
+	Voice ReplyMedia `xml:"Voice"`
+}
+
+// NewVoiceReply creates a voice reply to the message with an uploaded media
+func NewVoiceReply(msg Message, mediaId string) *VoiceReply {
+	return &VoiceReply{
+		ReplyHeader: newReplyHeader(msg, ReplyTypeVoice),
+		Voice:       ReplyMedia{MediaId: CDATA(mediaId)},
+	}
+}
+
+type ReplyVideo struct {
+	MediaId     CDATA `xml:"MediaId"`
+	Title       CDATA `xml:"Title,omitempty"`
+	Description CDATA `xml:"Description,omitempty"`
+}
+
+type VideoReply struct {
+	XMLName xml.Name `xml:"xml"`
+	ReplyHeader
+	Video ReplyVideo `xml:"Video"`
+}
+
+// NewVideoReply creates a video reply to the message with an uploaded media
+func NewVideoReply(msg Message, video ReplyVideo) *VideoReply {
+	return &VideoReply{
+		ReplyHeader: newReplyHeader(msg, ReplyTypeVideo),
+		Video:       video,
+	}
+}
+
+type ReplyMusic struct {
+	Title        CDATA `xml:"Title,omitempty"`
+	Description  CDATA `xml:"Description,omitempty"`
+	MusicUrl     CDATA `xml:"MusicUrl,omitempty"`
+	HQMusicUrl   CDATA `xml:"HQMusicUrl,omitempty"` // Played first on wifi
+	ThumbMediaId CDATA `xml:"ThumbMediaId"`
+}
+
+type MusicReply struct {
+	XMLName xml.Name `xml:"xml"`
+	ReplyHeader
+	Music ReplyMusic `xml:"Music"`
+}
+
+// NewMusicReply creates a music reply to the message
+func NewMusicReply(msg Message, music ReplyMusic) *MusicReply {
+	return &MusicReply{
+		ReplyHeader: newReplyHeader(msg, ReplyTypeMusic),
+		Music:       music,
+	}
+}
+
+type ReplyArticle struct {
+	Title       CDATA `xml:"Title"`
+	Description CDATA `xml:"Description"`
+	PicUrl      CDATA `xml:"PicUrl"`
+	Url         CDATA `xml:"Url"`
+}
+
+type NewsReply struct {
+	XMLName xml.Name `xml:"xml"`
+	ReplyHeader
+	ArticleCount int            `xml:"ArticleCount"`
+	Articles     []ReplyArticle `xml:"Articles>item"`
+}
+
+// NewNewsReply creates a news reply to the message, at most 8 articles are allowed
+func NewNewsReply(msg Message, articles ...ReplyArticle) (*NewsReply, error) {
+	if len(articles) > MaxNewsArticles {
+		return nil, ErrTooManyArticles
+	}
+	return &NewsReply{
+		ReplyHeader:  newReplyHeader(msg, ReplyTypeNews),
+		ArticleCount: len(articles),
+		Articles:     articles,
+	}, nil
+}
+
+type TransInfo struct {
+	KfAccount CDATA `xml:"KfAccount"`
+}
+
+// TransferCustomerServiceReply forwards the message to the customer service
+type TransferCustomerServiceReply struct {
+	XMLName xml.Name `xml:"xml"`
+	ReplyHeader
+	TransInfo *TransInfo `xml:"TransInfo,omitempty"`
+}
+
+// NewTransferCustomerServiceReply forwards the message to the customer service account,
+// it is forwarded to any online account if the account is empty.
+// https://developers.weixin.qq.com/doc/offiaccount/Customer_Service/Forwarding_of_messages_to_service_center.html
+func NewTransferCustomerServiceReply(msg Message, kfAccount string) *TransferCustomerServiceReply {
+	rv := &TransferCustomerServiceReply{
+		ReplyHeader: newReplyHeader(msg, ReplyTypeTransferCustomerService),
+	}
+	if kfAccount != "" {
+		rv.TransInfo = &TransInfo{KfAccount: CDATA(kfAccount)}
+	}
+	return rv
+}
@@ -0,0 +1,91 @@
+package messages_test
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat/officialaccount/messages"
+	"github.com/stretchr/testify/assert"
+)
+
+const replyHeader = "<ToUserName><![CDATA[fromUser]]></ToUserName><FromUserName><![CDATA[toUser]]></FromUserName><CreateTime>12345678</CreateTime>"
+
+var inbound = &messages.Text{Header: messages.Header{ToUserName: "toUser", FromUserName: "fromUser", MsgType: messages.MsgTypeText}}
+
+func assertReplyXML(t *testing.T, expected string, reply messages.Reply) {
+	assert.EqualValues(t, "fromUser", reply.GetReplyHeader().ToUserName)
+	assert.EqualValues(t, "toUser", reply.GetReplyHeader().FromUserName)
+	assert.NotZero(t, reply.GetReplyHeader().CreateTime)
+
+	reply.GetReplyHeader().CreateTime = 12345678
+	data, err := xml.Marshal(reply)
+	assert.NoError(t, err)
+	assert.Equal(t, "<xml>"+replyHeader+expected+"</xml>", string(data))
+}
+
+func TestReplies(t *testing.T) {
+	assertReplyXML(
+		t,
+		"<MsgType><![CDATA[text]]></MsgType><Content><![CDATA[<a href=\"url\">]]]]><![CDATA[>]]></Content>",
+		messages.NewTextReply(inbound, `<a href="url">]]>`),
+	)
+	assertReplyXML(
+		t,
+		"<MsgType><![CDATA[image]]></MsgType><Image><MediaId><![CDATA[media_id]]></MediaId></Image>",
+		messages.NewImageReply(inbound, "media_id"),
+	)
+	assertReplyXML(
+		t,
+		"<MsgType><![CDATA[voice]]></MsgType><Voice><MediaId><![CDATA[media_id]]></MediaId></Voice>",
+		messages.NewVoiceReply(inbound, "media_id"),
+	)
+	assertReplyXML(
+		t,
+		"<MsgType><![CDATA[video]]></MsgType><Video><MediaId><![CDATA[media_id]]></MediaId>"+
+			"<Title><![CDATA[title]]></Title></Video>",
+		messages.NewVideoReply(inbound, messages.ReplyVideo{MediaId: "media_id", Title: "title"}),
+	)
+	assertReplyXML(
+		t,
+		"<MsgType><![CDATA[music]]></MsgType><Music><Title><![CDATA[title]]></Title>"+
+			"<MusicUrl><![CDATA[url]]></MusicUrl><HQMusicUrl><![CDATA[hq_url]]></HQMusicUrl>"+
+			"<ThumbMediaId><![CDATA[thumb_media_id]]></ThumbMediaId></Music>",
+		messages.NewMusicReply(inbound, messages.ReplyMusic{
+			Title:        "title",
+			MusicUrl:     "url",
+			HQMusicUrl:   "hq_url",
+			ThumbMediaId: "thumb_media_id",
+		}),
+	)
+	assertReplyXML(
+		t,
+		"<MsgType><![CDATA[transfer_customer_service]]></MsgType>",
+		messages.NewTransferCustomerServiceReply(inbound, ""),
+	)
+	assertReplyXML(
+		t,
+		"<MsgType><![CDATA[transfer_customer_service]]></MsgType><TransInfo><KfAccount><![CDATA[test1@test]]></KfAccount></TransInfo>",
+		messages.NewTransferCustomerServiceReply(inbound, "test1@test"),
+	)
+
+	assert.Nil(t, messages.NewEmptyReply().GetReplyHeader())
+}
+
+func TestNewsReply(t *testing.T) {
+	article := messages.ReplyArticle{Title: "title", Description: "description", PicUrl: "pic_url", Url: "url"}
+	reply, err := messages.NewNewsReply(inbound, article, article)
+	assert.NoError(t, err)
+	item := "<item><Title><![CDATA[title]]></Title><Description><![CDATA[description]]></Description>" +
+		"<PicUrl><![CDATA[pic_url]]></PicUrl><Url><![CDATA[url]]></Url></item>"
+	assertReplyXML(
+		t,
+		"<MsgType><![CDATA[news]]></MsgType><ArticleCount>2</ArticleCount><Articles>"+item+item+"</Articles>",
+		reply,
+	)
+
+	articles := make([]messages.ReplyArticle, messages.MaxNewsArticles+1)
+	_, err = messages.NewNewsReply(inbound, articles[:messages.MaxNewsArticles]...)
+	assert.NoError(t, err)
+	_, err = messages.NewNewsReply(inbound, articles...)
+	assert.ErrorIs(t, err, messages.ErrTooManyArticles)
+}
@@ -0,0 +1,164 @@
+package officialaccount
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/Xavier-Lam/go-wechat/officialaccount/messages"
+)
+
+const DefaultSessionExpiresIn = 1800
+
+// LoggingMiddleware logs every routed message with its handling result
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	return func(next RouteHandler) RouteHandler {
+		return func(ctx context.Context, oa *OfficialAccount, msg messages.Message) (messages.Reply, error) {
+			start := time.Now()
+			reply, err := next(ctx, oa, msg)
+
+			header := msg.GetHeader()
+			attrs := []slog.Attr{
+				slog.String("msg_type", header.MsgType),
+				slog.String("openid", header.FromUserName),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if e, ok := msg.(messages.EventMessage); ok {
+				attrs = append(attrs, slog.String("event", e.GetEvent()))
+			}
+			if reply != nil && reply.GetReplyHeader() != nil {
+				attrs = append(attrs, slog.String("reply_type", string(reply.GetReplyHeader().MsgType)))
+			}
+			level := slog.LevelInfo
+			if err != nil {
+				level = slog.LevelError
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+			logger.LogAttrs(ctx, level, "wechat message handled", attrs...)
+
+			return reply, err
+		}
+	}
+}
+
+// RecoveryMiddleware turns a panic in the handler into an error, so that a message is never left unanswered
+func RecoveryMiddleware() Middleware {
+	return func(next RouteHandler) RouteHandler {
+		return func(ctx context.Context, oa *OfficialAccount, msg messages.Message) (reply messages.Reply, err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					reply = nil
+					err = fmt.Errorf("handler panicked: %v\n%s", recovered, debug.Stack())
+				}
+			}()
+			return next(ctx, oa, msg)
+		}
+	}
+}
+
+// Session holds the state of the conversation with a user
+type Session struct {
+	OpenId   string
+	values   map[string]string
+	modified bool
+}
+
+func (s *Session) Get(key string) (string, bool) {
+	value, ok := s.values[key]
+	return value, ok
+}
+
+func (s *Session) Set(key string, value string) {
+	s.values[key] = value
+	s.modified = true
+}
+
+func (s *Session) Delete(key string) {
+	delete(s.values, key)
+	s.modified = true
+}
+
+// Clear removes all values, the session is deleted from the cache
+func (s *Session) Clear() {
+	s.values = make(map[string]string)
+	s.modified = true
+}
+
+type sessionKey struct{}
+
+// SessionFromContext returns the session of the user sending the message,
+// it is nil if `SessionMiddleware` is not used.
+func SessionFromContext(ctx context.Context) *Session {
+	session, _ := ctx.Value(sessionKey{}).(*Session)
+	return session
+}
+
+type SessionConfig struct {
+	Cache        caches.Cache // Cache instance storing the sessions
+	ExpiresIn    int          // Sessions expire after it in seconds of inactivity, default value is 1800
+	ErrorHandler func(error)  // Called when a session failed to be decoded or saved, errors are ignored if not given
+}
+
+// SessionMiddleware loads the session of the user sending the message from the cache,
+// and saves it after the message is handled if it is modified.
+// A malformed session is replaced by an empty one, and failing to save a session
+// does not discard the reply of the handler, both are reported to `ErrorHandler`.
+func SessionMiddleware(conf SessionConfig) Middleware {
+	if conf.ExpiresIn <= 0 {
+		conf.ExpiresIn = DefaultSessionExpiresIn
+	}
+	handleError := func(err error) {
+		if conf.ErrorHandler != nil {
+			conf.ErrorHandler(err)
+		}
+	}
+	return func(next RouteHandler) RouteHandler {
+		return func(ctx context.Context, oa *OfficialAccount, msg messages.Message) (messages.Reply, error) {
+			appId := oa.Apis.GetAuth().GetAppId()
+			openId := msg.GetHeader().FromUserName
+			key := caches.BizSession + ":" + openId
+
+			session := &Session{OpenId: openId, values: make(map[string]string)}
+			data, err := conf.Cache.Get(appId, key)
+			if err == nil {
+				if err := json.Unmarshal(data, &session.values); err != nil {
+					handleError(fmt.Errorf("malformed session of %s: %w", openId, err))
+					session.values = make(map[string]string)
+					session.modified = true
+				}
+			} else if !errors.Is(err, caches.ErrKeyNotFound) {
+				return nil, fmt.Errorf("loading session failed: %w", err)
+			}
+
+			reply, err := next(context.WithValue(ctx, sessionKey{}, session), oa, msg)
+
+			// Renews the expiry of an active session even if it is not modified
+			if session.modified || len(session.values) > 0 {
+				if saveErr := saveSession(conf.Cache, appId, key, session, conf.ExpiresIn); saveErr != nil {
+					handleError(fmt.Errorf("saving session of %s failed: %w", openId, saveErr))
+				}
+			}
+			return reply, err
+		}
+	}
+}
+
+func saveSession(cache caches.Cache, appId, key string, session *Session, expiresIn int) error {
+	if len(session.values) == 0 {
+		err := cache.Delete(appId, key, nil)
+		if errors.Is(err, caches.ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	}
+	data, err := json.Marshal(session.values)
+	if err != nil {
+		return err
+	}
+	return cache.Set(appId, key, data, expiresIn)
+}
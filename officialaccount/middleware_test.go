@@ -0,0 +1,147 @@
+package officialaccount_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/Xavier-Lam/go-wechat/officialaccount"
+	"github.com/Xavier-Lam/go-wechat/officialaccount/messages"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+	middleware := func(name string) officialaccount.Middleware {
+		return func(next officialaccount.RouteHandler) officialaccount.RouteHandler {
+			return func(ctx context.Context, oa *officialaccount.OfficialAccount, msg messages.Message) (messages.Reply, error) {
+				calls = append(calls, name)
+				return next(ctx, oa, msg)
+			}
+		}
+	}
+
+	router := officialaccount.NewRouter(newMockOfficialAccount(nil))
+	router.Use(middleware("outer"), middleware("inner"))
+
+	// Middlewares are applied even if no route matches
+	reply, err := router.HandleMessage(context.Background(), textMessage(t, "hi"))
+	assert.NoError(t, err)
+	assert.Nil(t, reply)
+	assert.Equal(t, []string{"outer", "inner"}, calls)
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	buf := &bytes.Buffer{}
+	router := officialaccount.NewRouter(newMockOfficialAccount(nil))
+	router.Use(officialaccount.LoggingMiddleware(slog.New(slog.NewJSONHandler(buf, nil))))
+	router.Handle(named("menu"), officialaccount.MatchMenuKey("MENU_KEY"))
+
+	routed(t, router, eventMessage(t, messages.EventClick, "MENU_KEY"))
+	output := buf.String()
+	assert.Contains(t, output, `"msg_type":"event"`)
+	assert.Contains(t, output, `"event":"CLICK"`)
+	assert.Contains(t, output, `"openid":"fromUser"`)
+	assert.Contains(t, output, `"reply_type":"text"`)
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	router := officialaccount.NewRouter(newMockOfficialAccount(nil))
+	router.Use(officialaccount.RecoveryMiddleware())
+	router.Fallback(func(ctx context.Context, oa *officialaccount.OfficialAccount, msg messages.Message) (messages.Reply, error) {
+		panic("something went wrong")
+	})
+
+	reply, err := router.HandleMessage(context.Background(), textMessage(t, "hi"))
+	assert.Nil(t, reply)
+	assert.ErrorContains(t, err, "something went wrong")
+}
+
+func TestSessionMiddleware(t *testing.T) {
+	cache := caches.NewDummyCache()
+	router := officialaccount.NewRouter(newMockOfficialAccount(nil))
+	router.Use(officialaccount.SessionMiddleware(officialaccount.SessionConfig{Cache: cache}))
+	router.Handle(func(ctx context.Context, oa *officialaccount.OfficialAccount, msg messages.Message) (messages.Reply, error) {
+		session := officialaccount.SessionFromContext(ctx)
+		session.Clear()
+		return messages.NewTextReply(msg, "cancelled"), nil
+	}, officialaccount.MatchText("cancel"))
+	router.Fallback(func(ctx context.Context, oa *officialaccount.OfficialAccount, msg messages.Message) (messages.Reply, error) {
+		session := officialaccount.SessionFromContext(ctx)
+		assert.Equal(t, msg.GetHeader().FromUserName, session.OpenId)
+		last, _ := session.Get("last")
+		session.Set("last", msg.(*messages.Text).Content)
+		return messages.NewTextReply(msg, "last: "+last), nil
+	})
+
+	assert.Equal(t, "last: ", routed(t, router, textMessage(t, "first")))
+	assert.Equal(t, "last: first", routed(t, router, textMessage(t, "second")))
+
+	data, err := cache.Get(serverAuth.GetAppId(), caches.BizSession+":fromUser")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"last": "second"}`, string(data))
+
+	// Sessions are isolated by openid
+	other := decodeMessage(t, `<xml><FromUserName>otherUser</FromUserName><MsgType>text</MsgType><Content>other</Content></xml>`)
+	assert.Equal(t, "last: ", routed(t, router, other))
+
+	assert.Equal(t, "cancelled", routed(t, router, textMessage(t, "cancel")))
+	_, err = cache.Get(serverAuth.GetAppId(), caches.BizSession+":fromUser")
+	assert.ErrorIs(t, err, caches.ErrKeyNotFound)
+	assert.Equal(t, "last: ", routed(t, router, textMessage(t, "third")))
+
+	assert.Nil(t, officialaccount.SessionFromContext(context.Background()))
+}
+
+type failingSetCache struct {
+	caches.Cache
+}
+
+func (c *failingSetCache) Set(appId string, key string, value []byte, expiresIn int) error {
+	return errors.New("cache unavailable")
+}
+
+func TestSessionMiddlewareErrors(t *testing.T) {
+	cache := caches.NewDummyCache()
+	var errs []error
+	router := officialaccount.NewRouter(newMockOfficialAccount(nil))
+	router.Use(officialaccount.SessionMiddleware(officialaccount.SessionConfig{
+		Cache: cache,
+		ErrorHandler: func(err error) {
+			errs = append(errs, err)
+		},
+	}))
+	router.Fallback(func(ctx context.Context, oa *officialaccount.OfficialAccount, msg messages.Message) (messages.Reply, error) {
+		session := officialaccount.SessionFromContext(ctx)
+		last, _ := session.Get("last")
+		session.Set("last", msg.(*messages.Text).Content)
+		return messages.NewTextReply(msg, "last: "+last), nil
+	})
+
+	// A malformed session is replaced
+	cache.Set(serverAuth.GetAppId(), caches.BizSession+":fromUser", []byte("malformed"), 1800)
+	assert.Equal(t, "last: ", routed(t, router, textMessage(t, "first")))
+	assert.Equal(t, "last: first", routed(t, router, textMessage(t, "second")))
+	assert.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "malformed session")
+
+	// The reply is kept if the session failed to be saved
+	errs = nil
+	router = officialaccount.NewRouter(newMockOfficialAccount(nil))
+	router.Use(officialaccount.SessionMiddleware(officialaccount.SessionConfig{
+		Cache: &failingSetCache{caches.NewDummyCache()},
+		ErrorHandler: func(err error) {
+			errs = append(errs, err)
+		},
+	}))
+	router.Fallback(func(ctx context.Context, oa *officialaccount.OfficialAccount, msg messages.Message) (messages.Reply, error) {
+		officialaccount.SessionFromContext(ctx).Set("key", "value")
+		return messages.NewTextReply(msg, "reply"), nil
+	})
+	assert.Equal(t, "reply", routed(t, router, textMessage(t, "hi")))
+	assert.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "cache unavailable")
+}
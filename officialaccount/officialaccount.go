@@ -1,7 +1,10 @@
 package officialaccount
 
 import (
+	"context"
+	"log/slog"
 	"net/url"
+	"time"
 
 	"github.com/Xavier-Lam/go-wechat"
 	"github.com/Xavier-Lam/go-wechat/caches"
@@ -10,10 +13,17 @@ import (
 )
 
 type Config struct {
-	HttpClient        client.HttpClient        // Default Http client to send request
-	Cache             caches.Cache             // Cache instance for managing tokens
-	AccessTokenClient client.AccessTokenClient // The client used for request access token
-	BaseApiUri        *url.URL                 // The endpoint to request an API, if full path is not given, default value is 'https://api.weixin.qq.com'
+	HttpClient        client.HttpClient         // Default Http client to send request
+	Cache             caches.Cache              // Cache instance for managing tokens
+	AccessTokenClient client.AccessTokenClient  // The client used for request access token
+	BaseApiUri        *url.URL                  // The endpoint to request an API, if full path is not given, default value is 'https://api.weixin.qq.com'
+	TokenLock         *client.TokenLockConfig   // Lock in `Cache` before fetching an access token, disabled if not given
+	Interceptors      []client.Interceptor      // Interceptors of every attempt to send a request, the first one is the outermost
+	RetryPolicy       *client.RetryPolicy       // Policy to retry failed requests, requests are only retried after refreshing a rejected token if not given
+	RateLimiter       *client.RateLimiterConfig // Client-side rate limiter of every attempt to send a request, disabled if not given
+	Observer          client.Observer           // Receives traces and metrics, default value is `client.NoopObserver`
+	Logger            *slog.Logger              // Logs requests and retries with credentials redacted, disabled if not given
+	TokenFetchTimeout time.Duration             // Max time to fetch an access token shared by concurrent callers, default value is 30s
 }
 
 type OfficialAccount struct {
@@ -22,12 +32,30 @@ type OfficialAccount struct {
 	Js js
 }
 
+// StartRefresher renews the access token and the JS ticket in background
+// before they expire, until `ctx` is done or the returned `Refresher` is stopped.
+func (oa *OfficialAccount) StartRefresher(ctx context.Context, conf client.RefresherConfig) *client.Refresher {
+	return client.StartRefresher(
+		ctx,
+		conf,
+		client.AccessTokenRefreshable(oa.Apis.WeChatClient),
+		&oa.Js,
+	)
+}
+
 func New(auth wechat.Auth, conf Config) *OfficialAccount { // Set up base dependencies if not given
 	c := client.New(auth, client.Config{
 		HttpClient:        conf.HttpClient,
 		Cache:             conf.Cache,
 		AccessTokenClient: conf.AccessTokenClient,
 		BaseApiUri:        conf.BaseApiUri,
+		TokenLock:         conf.TokenLock,
+		Interceptors:      conf.Interceptors,
+		RetryPolicy:       conf.RetryPolicy,
+		RateLimiter:       conf.RateLimiter,
+		Observer:          conf.Observer,
+		Logger:            conf.Logger,
+		TokenFetchTimeout: conf.TokenFetchTimeout,
 	})
 	a := apis.NewApis(c)
 	return &OfficialAccount{
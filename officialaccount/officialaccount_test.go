@@ -0,0 +1,43 @@
+package officialaccount_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Xavier-Lam/go-wechat"
+	"github.com/Xavier-Lam/go-wechat/client"
+	"github.com/Xavier-Lam/go-wechat/internal/test"
+	"github.com/Xavier-Lam/go-wechat/officialaccount"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClientConfig(t *testing.T) {
+	mc := test.NewMockHttpClient(func(req *http.Request, calls int) (*http.Response, error) {
+		if calls == 1 {
+			return test.Responses.Json(`{"errcode": -1, "errmsg": "system error"}`)
+		}
+		return test.Responses.Empty()
+	})
+
+	intercepted := 0
+	oa := officialaccount.New(wechat.NewAuth("app-id", "app-secret"), officialaccount.Config{
+		AccessTokenClient: test.NewMockAccessTokenClient("mock-access-token"),
+		HttpClient:        mc,
+		Interceptors: []client.Interceptor{
+			func(req *http.Request, next client.Invoker) (*http.Response, error) {
+				intercepted++
+				return next(req)
+			},
+		},
+		RetryPolicy: &client.RetryPolicy{
+			MaxAttempts:    2,
+			ErrCodes:       []int{client.ErrCodeSystemBusy},
+			InitialBackoff: time.Millisecond,
+		},
+	})
+
+	_, err := oa.Apis.WeChatClient.Get("/some-endpoint", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, intercepted)
+}
@@ -0,0 +1,155 @@
+package officialaccount
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Xavier-Lam/go-wechat/officialaccount/messages"
+)
+
+// RouteHandler handles a routed message, the official account is given for calling APIs
+type RouteHandler func(ctx context.Context, oa *OfficialAccount, msg messages.Message) (messages.Reply, error)
+
+// Middleware wraps the handler of every routed message
+type Middleware func(next RouteHandler) RouteHandler
+
+// Matcher reports whether a message should be handled by a route
+type Matcher func(msg messages.Message) bool
+
+type route struct {
+	matchers []Matcher
+	handler  RouteHandler
+}
+
+// Router dispatches messages to the first route whose matchers all match,
+// it is registered to a `Server` by `server.HandleDefault(router.HandleMessage)`.
+type Router struct {
+	oa          *OfficialAccount
+	mu          sync.RWMutex
+	routes      []route
+	fallback    RouteHandler
+	middlewares []Middleware
+}
+
+func NewRouter(oa *OfficialAccount) *Router {
+	return &Router{oa: oa}
+}
+
+// Use appends middlewares, the first one is the outermost
+func (r *Router) Use(middlewares ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// Handle adds a route handling the messages matching all the matchers,
+// routes are matched in the order they are added.
+func (r *Router) Handle(handler RouteHandler, matchers ...Matcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = append(r.routes, route{matchers: matchers, handler: handler})
+}
+
+// Fallback sets the handler of the messages matching no route
+func (r *Router) Fallback(handler RouteHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = handler
+}
+
+// HandleMessage is a `MessageHandler` routing the message,
+// the message is acknowledged without replying if no route matches.
+func (r *Router) HandleMessage(ctx context.Context, msg messages.Message) (messages.Reply, error) {
+	r.mu.RLock()
+	handler := r.match(msg)
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		handler = r.middlewares[i](handler)
+	}
+	r.mu.RUnlock()
+
+	return handler(ctx, r.oa, msg)
+}
+
+func (r *Router) match(msg messages.Message) RouteHandler {
+	for _, route := range r.routes {
+		if matchAll(msg, route.matchers) {
+			return route.handler
+		}
+	}
+	if r.fallback != nil {
+		return r.fallback
+	}
+	return func(ctx context.Context, oa *OfficialAccount, msg messages.Message) (messages.Reply, error) {
+		return nil, nil
+	}
+}
+
+func matchAll(msg messages.Message, matchers []Matcher) bool {
+	for _, matcher := range matchers {
+		if !matcher(msg) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchMsgType matches the messages of the type
+func MatchMsgType(msgType string) Matcher {
+	return func(msg messages.Message) bool {
+		return msg.GetHeader().MsgType == msgType
+	}
+}
+
+// MatchEvent matches the events of the type, the type is case sensitive
+func MatchEvent(event string) Matcher {
+	return func(msg messages.Message) bool {
+		e, ok := msg.(messages.EventMessage)
+		return ok && e.GetEvent() == event
+	}
+}
+
+// MatchText matches the text messages of the exact content
+func MatchText(content string) Matcher {
+	return func(msg messages.Message) bool {
+		text, ok := msg.(*messages.Text)
+		return ok && text.Content == content
+	}
+}
+
+// MatchTextRegexp matches the text messages whose content matches the pattern
+func MatchTextRegexp(pattern *regexp.Regexp) Matcher {
+	return func(msg messages.Message) bool {
+		text, ok := msg.(*messages.Text)
+		return ok && pattern.MatchString(text.Content)
+	}
+}
+
+// MatchEventKeyPrefix matches the events whose `EventKey` starts with the prefix,
+// such as "qrscene_" for the subscriptions by scanning parametric QR codes.
+func MatchEventKeyPrefix(prefix string) Matcher {
+	return func(msg messages.Message) bool {
+		e, ok := msg.(messages.KeyedEvent)
+		return ok && strings.HasPrefix(e.GetEventKey(), prefix)
+	}
+}
+
+// The events triggered by the menu items with a key
+var menuEvents = map[string]bool{
+	messages.EventClick:           true,
+	messages.EventScancodePush:    true,
+	messages.EventScancodeWaitMsg: true,
+	messages.EventPicSysPhoto:     true,
+	messages.EventPicPhotoOrAlbum: true,
+	messages.EventPicWeixin:       true,
+	messages.EventLocationSelect:  true,
+}
+
+// MatchMenuKey matches the events triggered by the menu item of the key
+func MatchMenuKey(key string) Matcher {
+	return func(msg messages.Message) bool {
+		e, ok := msg.(messages.KeyedEvent)
+		return ok && menuEvents[e.GetEvent()] && e.GetEventKey() == key
+	}
+}
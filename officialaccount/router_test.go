@@ -0,0 +1,116 @@
+package officialaccount_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat/internal/test"
+	"github.com/Xavier-Lam/go-wechat/officialaccount"
+	"github.com/Xavier-Lam/go-wechat/officialaccount/messages"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMockOfficialAccount(handler test.RequestHandler) *officialaccount.OfficialAccount {
+	return officialaccount.New(serverAuth, officialaccount.Config{
+		AccessTokenClient: test.NewMockAccessTokenClient("mock-access-token"),
+		HttpClient:        test.NewMockHttpClient(handler),
+	})
+}
+
+func decodeMessage(t *testing.T, format string, args ...interface{}) messages.Message {
+	msg, err := messages.Decode([]byte(fmt.Sprintf(format, args...)))
+	assert.NoError(t, err)
+	return msg
+}
+
+func textMessage(t *testing.T, content string) messages.Message {
+	return decodeMessage(
+		t,
+		`<xml><ToUserName>toUser</ToUserName><FromUserName>fromUser</FromUserName><MsgType>text</MsgType><Content>%s</Content></xml>`,
+		content,
+	)
+}
+
+func eventMessage(t *testing.T, event, eventKey string) messages.Message {
+	return decodeMessage(
+		t,
+		`<xml><ToUserName>toUser</ToUserName><FromUserName>fromUser</FromUserName><MsgType>event</MsgType><Event>%s</Event><EventKey>%s</EventKey></xml>`,
+		event,
+		eventKey,
+	)
+}
+
+// Returns a handler replying with the name of the route
+func named(name string) officialaccount.RouteHandler {
+	return func(ctx context.Context, oa *officialaccount.OfficialAccount, msg messages.Message) (messages.Reply, error) {
+		return messages.NewTextReply(msg, name), nil
+	}
+}
+
+func routed(t *testing.T, router *officialaccount.Router, msg messages.Message) string {
+	reply, err := router.HandleMessage(context.Background(), msg)
+	assert.NoError(t, err)
+	if reply == nil {
+		return ""
+	}
+	return string(reply.(*messages.TextReply).Content)
+}
+
+func TestRouterMatchers(t *testing.T) {
+	router := officialaccount.NewRouter(newMockOfficialAccount(nil))
+	router.Handle(named("exact"), officialaccount.MatchText("help"))
+	router.Handle(named("regexp"), officialaccount.MatchTextRegexp(regexp.MustCompile(`^order \d+$`)))
+	router.Handle(named("qrscene"), officialaccount.MatchEvent(messages.EventSubscribe), officialaccount.MatchEventKeyPrefix("qrscene_"))
+	router.Handle(named("subscribe"), officialaccount.MatchEvent(messages.EventSubscribe))
+	router.Handle(named("menu"), officialaccount.MatchMenuKey("MENU_KEY"))
+	router.Handle(named("scan"), officialaccount.MatchEventKeyPrefix("scene_"))
+	router.Handle(named("image"), officialaccount.MatchMsgType(messages.MsgTypeImage))
+
+	assert.Equal(t, "exact", routed(t, router, textMessage(t, "help")))
+	assert.Equal(t, "regexp", routed(t, router, textMessage(t, "order 123")))
+	assert.Equal(t, "", routed(t, router, textMessage(t, "order abc")))
+	assert.Equal(t, "qrscene", routed(t, router, eventMessage(t, messages.EventSubscribe, "qrscene_123")))
+	assert.Equal(t, "subscribe", routed(t, router, eventMessage(t, messages.EventSubscribe, "")))
+	assert.Equal(t, "menu", routed(t, router, eventMessage(t, messages.EventClick, "MENU_KEY")))
+	assert.Equal(t, "menu", routed(t, router, eventMessage(t, messages.EventScancodePush, "MENU_KEY")))
+	assert.Equal(t, "", routed(t, router, eventMessage(t, messages.EventClick, "OTHER_KEY")))
+	// The url of a view event is not a menu key
+	assert.Equal(t, "", routed(t, router, eventMessage(t, messages.EventView, "MENU_KEY")))
+	assert.Equal(t, "scan", routed(t, router, eventMessage(t, messages.EventScan, "scene_1")))
+	assert.Equal(t, "image", routed(t, router, decodeMessage(t, `<xml><MsgType>image</MsgType></xml>`)))
+
+	router.Fallback(named("fallback"))
+	assert.Equal(t, "fallback", routed(t, router, textMessage(t, "order abc")))
+	assert.Equal(t, "fallback", routed(t, router, eventMessage(t, "unknown_event", "")))
+}
+
+func TestRouterCallsApis(t *testing.T) {
+	oa := newMockOfficialAccount(func(req *http.Request, calls int) (*http.Response, error) {
+		test.AssertEndpointEqual(t, "https://api.weixin.qq.com/cgi-bin/user/info", req.URL)
+		assert.Equal(t, "fromUser", req.URL.Query().Get("openid"))
+		return test.Responses.Json(`{"subscribe": 1, "openid": "fromUser", "remark": "Alice"}`)
+	})
+	router := officialaccount.NewRouter(oa)
+	router.Fallback(func(ctx context.Context, oa *officialaccount.OfficialAccount, msg messages.Message) (messages.Reply, error) {
+		info, err := oa.Apis.User.GetInfo(msg.GetHeader().FromUserName, "zh_CN")
+		if err != nil {
+			return nil, err
+		}
+		return messages.NewTextReply(msg, "hello "+info.Remark), nil
+	})
+
+	s := newServer(t, officialaccount.ServerConfig{})
+	s.HandleDefault(router.HandleMessage)
+	resp := serve(s, http.MethodPost, signedUrl(url.Values{}), `<xml>
+		<ToUserName><![CDATA[toUser]]></ToUserName>
+		<FromUserName><![CDATA[fromUser]]></FromUserName>
+		<CreateTime>1348831860</CreateTime>
+		<MsgType><![CDATA[text]]></MsgType>
+		<Content><![CDATA[hi]]></Content>
+	</xml>`)
+	assert.Contains(t, resp.Body.String(), "<Content><![CDATA[hello Alice]]></Content>")
+}
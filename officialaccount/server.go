@@ -0,0 +1,187 @@
+package officialaccount
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Xavier-Lam/go-wechat"
+	"github.com/Xavier-Lam/go-wechat/msgcrypt"
+	"github.com/Xavier-Lam/go-wechat/officialaccount/messages"
+)
+
+// The max size of an inbound message
+const maxMessageSize = 1 << 20
+
+// The response to acknowledge a message without replying
+const successResponse = "success"
+
+// The value of `encrypt_type` of the messages encrypted in safe mode
+const encryptTypeAES = "aes"
+
+type ServerConfig struct {
+	Token          string      // The token set on the admin platform for verifying signatures
+	EncodingAESKey string      // The key of safe mode, encrypted messages are rejected if not given
+	ErrorHandler   func(error) // Called when a message failed to be handled, errors are ignored if not given
+}
+
+// MessageHandler handles an inbound message and returns a passive reply,
+// the message is acknowledged without replying if the reply is nil or a `*messages.EmptyReply`.
+type MessageHandler func(ctx context.Context, msg messages.Message) (messages.Reply, error)
+
+// Server is an `http.Handler` of the URL receiving messages and events pushed by WeChat
+// https://developers.weixin.qq.com/doc/offiaccount/Basic_Information/Access_Overview.html
+type Server struct {
+	auth     wechat.Auth
+	config   ServerConfig
+	mu       sync.RWMutex
+	handlers map[string]MessageHandler
+	fallback MessageHandler
+	crypt    *msgcrypt.MsgCrypt
+}
+
+// NewServer creates a `Server`, the replies are encrypted transparently
+// if the message is encrypted in safe mode.
+func NewServer(auth wechat.Auth, conf ServerConfig) (*Server, error) {
+	s := &Server{
+		auth:     auth,
+		config:   conf,
+		handlers: make(map[string]MessageHandler),
+	}
+	if conf.EncodingAESKey != "" {
+		crypt, err := msgcrypt.New(conf.Token, conf.EncodingAESKey, auth.GetAppId())
+		if err != nil {
+			return nil, err
+		}
+		s.crypt = crypt
+	}
+	return s, nil
+}
+
+// Handle registers the handler of the messages of the type
+func (s *Server) Handle(msgType string, handler MessageHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[msgType] = handler
+}
+
+// HandleEvent registers the handler of the events of the type, the type is case sensitive
+func (s *Server) HandleEvent(event string, handler MessageHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[eventHandlerKey(event)] = handler
+}
+
+// HandleDefault registers the handler of the messages without a handler of their type
+func (s *Server) HandleDefault(handler MessageHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fallback = handler
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !s.verify(query.Get("signature"), query.Get("timestamp"), query.Get("nonce")) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		// Verifying the server URL
+		io.WriteString(w, query.Get("echostr"))
+	case http.MethodPost:
+		s.serveMessage(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) serveMessage(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize))
+	if err != nil {
+		http.Error(w, "failed to read message", http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	encrypted := query.Get("encrypt_type") == encryptTypeAES
+	if encrypted {
+		if s.crypt == nil {
+			http.Error(w, "safe mode is not enabled", http.StatusBadRequest)
+			return
+		}
+		data, err = s.crypt.DecryptMessage(query.Get("msg_signature"), query.Get("timestamp"), query.Get("nonce"), data)
+		if err != nil {
+			http.Error(w, "failed to decrypt message", http.StatusBadRequest)
+			return
+		}
+	}
+	msg, err := messages.Decode(data)
+	if err != nil {
+		http.Error(w, "malformed message", http.StatusBadRequest)
+		return
+	}
+
+	// Errors are not reported to WeChat, otherwise the user is told the service is unavailable
+	reply, err := s.dispatch(r.Context(), msg)
+	if err != nil {
+		s.handleError(fmt.Errorf("handling message failed: %w", err))
+		reply = nil
+	}
+
+	if _, ok := reply.(*messages.EmptyReply); ok || reply == nil {
+		io.WriteString(w, successResponse)
+		return
+	}
+	body, err := xml.Marshal(reply)
+	if err == nil && encrypted {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		body, err = s.crypt.EncryptMessage(body, timestamp, query.Get("nonce"))
+	}
+	if err != nil {
+		s.handleError(fmt.Errorf("serializing reply failed: %w", err))
+		io.WriteString(w, successResponse)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write(body)
+}
+
+func (s *Server) dispatch(ctx context.Context, msg messages.Message) (messages.Reply, error) {
+	key := msg.GetHeader().MsgType
+	if event, ok := msg.(messages.EventMessage); ok {
+		key = eventHandlerKey(event.GetEvent())
+	}
+
+	s.mu.RLock()
+	handler, ok := s.handlers[key]
+	if !ok {
+		handler = s.fallback
+	}
+	s.mu.RUnlock()
+
+	if handler == nil {
+		return nil, nil
+	}
+	return handler(ctx, msg)
+}
+
+func (s *Server) verify(signature, timestamp, nonce string) bool {
+	return msgcrypt.Verify(signature, s.config.Token, timestamp, nonce)
+}
+
+func (s *Server) handleError(err error) {
+	if s.config.ErrorHandler != nil {
+		s.config.ErrorHandler(err)
+	}
+}
+
+func eventHandlerKey(event string) string {
+	return messages.MsgTypeEvent + ":" + event
+}
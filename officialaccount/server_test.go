@@ -0,0 +1,186 @@
+package officialaccount_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat"
+	"github.com/Xavier-Lam/go-wechat/msgcrypt"
+	"github.com/Xavier-Lam/go-wechat/officialaccount"
+	"github.com/Xavier-Lam/go-wechat/officialaccount/messages"
+	"github.com/stretchr/testify/assert"
+)
+
+const serverToken = "mock-server-token"
+
+var serverAuth = wechat.NewAuth("mock-app-id", "mock-app-secret")
+
+func signedUrl(query url.Values) string {
+	timestamp, nonce := "1409304348", "1215372165"
+	values := []string{serverToken, timestamp, nonce}
+	sort.Strings(values)
+	query.Set("signature", fmt.Sprintf("%x", sha1.Sum([]byte(strings.Join(values, "")))))
+	query.Set("timestamp", timestamp)
+	query.Set("nonce", nonce)
+	return "/callback?" + query.Encode()
+}
+
+func newServer(t *testing.T, conf officialaccount.ServerConfig) *officialaccount.Server {
+	conf.Token = serverToken
+	s, err := officialaccount.NewServer(serverAuth, conf)
+	assert.NoError(t, err)
+	return s
+}
+
+func serve(s http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return recorder
+}
+
+func TestServerVerify(t *testing.T) {
+	s := newServer(t, officialaccount.ServerConfig{})
+
+	resp := serve(s, http.MethodGet, signedUrl(url.Values{"echostr": {"echo"}}), "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "echo", resp.Body.String())
+
+	resp = serve(s, http.MethodGet, "/callback?signature=invalid&timestamp=1&nonce=2&echostr=echo", "")
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "echo")
+
+	resp = serve(s, http.MethodPut, signedUrl(url.Values{}), "")
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+}
+
+func TestServerDispatch(t *testing.T) {
+	var handled []string
+	s := newServer(t, officialaccount.ServerConfig{})
+	s.Handle(messages.MsgTypeText, func(ctx context.Context, msg messages.Message) (messages.Reply, error) {
+		text := msg.(*messages.Text)
+		handled = append(handled, "text")
+		return messages.NewTextReply(msg, "echo: "+text.Content), nil
+	})
+	s.HandleEvent(messages.EventSubscribe, func(ctx context.Context, msg messages.Message) (messages.Reply, error) {
+		handled = append(handled, "subscribe")
+		return messages.NewEmptyReply(), nil
+	})
+	s.HandleDefault(func(ctx context.Context, msg messages.Message) (messages.Reply, error) {
+		handled = append(handled, "default:"+msg.GetHeader().MsgType)
+		return nil, nil
+	})
+
+	resp := serve(s, http.MethodPost, signedUrl(url.Values{}), `<xml>
+		<ToUserName><![CDATA[toUser]]></ToUserName>
+		<FromUserName><![CDATA[fromUser]]></FromUserName>
+		<CreateTime>1348831860</CreateTime>
+		<MsgType><![CDATA[text]]></MsgType>
+		<Content><![CDATA[hello]]></Content>
+		<MsgId>1234567890123456</MsgId>
+	</xml>`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	body := resp.Body.String()
+	assert.Contains(t, body, "<ToUserName><![CDATA[fromUser]]></ToUserName>")
+	assert.Contains(t, body, "<FromUserName><![CDATA[toUser]]></FromUserName>")
+	assert.Contains(t, body, "<MsgType><![CDATA[text]]></MsgType>")
+	assert.Contains(t, body, "<Content><![CDATA[echo: hello]]></Content>")
+
+	resp = serve(s, http.MethodPost, signedUrl(url.Values{}), `<xml>
+		<ToUserName><![CDATA[toUser]]></ToUserName>
+		<FromUserName><![CDATA[fromUser]]></FromUserName>
+		<CreateTime>123456789</CreateTime>
+		<MsgType><![CDATA[event]]></MsgType>
+		<Event><![CDATA[subscribe]]></Event>
+	</xml>`)
+	assert.Equal(t, "success", resp.Body.String())
+
+	resp = serve(s, http.MethodPost, signedUrl(url.Values{}), `<xml>
+		<ToUserName><![CDATA[toUser]]></ToUserName>
+		<FromUserName><![CDATA[fromUser]]></FromUserName>
+		<CreateTime>1348831860</CreateTime>
+		<MsgType><![CDATA[unsupported]]></MsgType>
+	</xml>`)
+	assert.Equal(t, "success", resp.Body.String())
+
+	assert.Equal(t, []string{"text", "subscribe", "default:unsupported"}, handled)
+}
+
+func TestServerErrors(t *testing.T) {
+	var handledErr error
+	s := newServer(t, officialaccount.ServerConfig{
+		ErrorHandler: func(err error) { handledErr = err },
+	})
+	expectedErr := errors.New("handler failed")
+	s.HandleDefault(func(ctx context.Context, msg messages.Message) (messages.Reply, error) {
+		return messages.NewTextReply(msg, "unexpected"), expectedErr
+	})
+
+	resp := serve(s, http.MethodPost, signedUrl(url.Values{}), `<xml><MsgType>text</MsgType></xml>`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "success", resp.Body.String())
+	assert.ErrorIs(t, handledErr, expectedErr)
+
+	resp = serve(s, http.MethodPost, signedUrl(url.Values{}), `not xml`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = serve(s, http.MethodPost, "/callback", `<xml><MsgType>text</MsgType></xml>`)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
+
+func TestServerSafeMode(t *testing.T) {
+	encodingAESKey := "abcdefghijklmnopqrstuvwxyz0123456789ABCDEFG"
+	s := newServer(t, officialaccount.ServerConfig{EncodingAESKey: encodingAESKey})
+	s.Handle(messages.MsgTypeText, func(ctx context.Context, msg messages.Message) (messages.Reply, error) {
+		return messages.NewTextReply(msg, "echo: "+msg.(*messages.Text).Content), nil
+	})
+
+	crypt, err := msgcrypt.New(serverToken, encodingAESKey, serverAuth.GetAppId())
+	assert.NoError(t, err)
+	timestamp, nonce := "1409304348", "1215372165"
+	envelope, err := crypt.Encrypt([]byte(`<xml>
+		<ToUserName><![CDATA[toUser]]></ToUserName>
+		<FromUserName><![CDATA[fromUser]]></FromUserName>
+		<CreateTime>1348831860</CreateTime>
+		<MsgType><![CDATA[text]]></MsgType>
+		<Content><![CDATA[hello]]></Content>
+		<MsgId>1234567890123456</MsgId>
+	</xml>`), timestamp, nonce)
+	assert.NoError(t, err)
+	body := "<xml><ToUserName><![CDATA[toUser]]></ToUserName><Encrypt><![CDATA[" + string(envelope.Encrypt) + "]]></Encrypt></xml>"
+
+	target := signedUrl(url.Values{
+		"encrypt_type":  {"aes"},
+		"msg_signature": {string(envelope.MsgSignature)},
+	})
+	resp := serve(s, http.MethodPost, target, body)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	replyEnvelope := msgcrypt.Envelope{}
+	assert.NoError(t, xml.Unmarshal(resp.Body.Bytes(), &replyEnvelope))
+	assert.Equal(t, nonce, string(replyEnvelope.Nonce))
+	reply, err := crypt.DecryptMessage(string(replyEnvelope.MsgSignature), replyEnvelope.TimeStamp, nonce, resp.Body.Bytes())
+	assert.NoError(t, err)
+	assert.Contains(t, string(reply), "<Content><![CDATA[echo: hello]]></Content>")
+	assert.Contains(t, string(reply), "<ToUserName><![CDATA[fromUser]]></ToUserName>")
+
+	// Tampered
+	target = signedUrl(url.Values{"encrypt_type": {"aes"}, "msg_signature": {"invalid"}})
+	resp = serve(s, http.MethodPost, target, body)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	// Safe mode not enabled
+	resp = serve(newServer(t, officialaccount.ServerConfig{}), http.MethodPost, target, body)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	_, err = officialaccount.NewServer(serverAuth, officialaccount.ServerConfig{Token: serverToken, EncodingAESKey: "invalid"})
+	assert.ErrorIs(t, err, msgcrypt.ErrInvalidAESKey)
+}
@@ -0,0 +1,95 @@
+// Package wechatotel adapts OpenTelemetry to the `client.Observer` of go-wechat
+package wechatotel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Xavier-Lam/go-wechat/client"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/Xavier-Lam/go-wechat"
+
+type observer struct {
+	tracer     trace.Tracer
+	meter      metric.Meter
+	counters   map[string]metric.Int64Counter
+	histograms map[string]metric.Float64Histogram
+}
+
+// NewObserver creates a `client.Observer` emitting spans and metrics through OpenTelemetry
+func NewObserver(tp trace.TracerProvider, mp metric.MeterProvider) (client.Observer, error) {
+	o := &observer{
+		tracer:     tp.Tracer(instrumentationName),
+		meter:      mp.Meter(instrumentationName),
+		counters:   make(map[string]metric.Int64Counter),
+		histograms: make(map[string]metric.Float64Histogram),
+	}
+
+	for _, name := range []string{
+		client.MetricTokenCacheHit,
+		client.MetricTokenCacheMiss,
+		client.MetricTokenFetch,
+		client.MetricApiResult,
+		client.MetricRetry,
+	} {
+		counter, err := o.meter.Int64Counter(name)
+		if err != nil {
+			return nil, err
+		}
+		o.counters[name] = counter
+	}
+
+	histogram, err := o.meter.Float64Histogram(client.MetricHttpDuration, metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	o.histograms[client.MetricHttpDuration] = histogram
+
+	return o, nil
+}
+
+func (o *observer) StartSpan(ctx context.Context, name string, attrs ...client.Attribute) (context.Context, func(err error)) {
+	ctx, span := o.tracer.Start(ctx, name, trace.WithAttributes(convert(attrs)...))
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+func (o *observer) Count(ctx context.Context, name string, value int64, attrs ...client.Attribute) {
+	if counter, ok := o.counters[name]; ok {
+		counter.Add(ctx, value, metric.WithAttributes(convert(attrs)...))
+	}
+}
+
+func (o *observer) RecordDuration(ctx context.Context, name string, duration time.Duration, attrs ...client.Attribute) {
+	if histogram, ok := o.histograms[name]; ok {
+		histogram.Record(ctx, duration.Seconds(), metric.WithAttributes(convert(attrs)...))
+	}
+}
+
+func convert(attrs []client.Attribute) []attribute.KeyValue {
+	rv := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		switch v := attr.Value.(type) {
+		case string:
+			rv = append(rv, attribute.String(attr.Key, v))
+		case int:
+			rv = append(rv, attribute.Int(attr.Key, v))
+		case bool:
+			rv = append(rv, attribute.Bool(attr.Key, v))
+		default:
+			rv = append(rv, attribute.String(attr.Key, fmt.Sprint(v)))
+		}
+	}
+	return rv
+}
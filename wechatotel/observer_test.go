@@ -0,0 +1,82 @@
+package wechatotel_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Xavier-Lam/go-wechat"
+	"github.com/Xavier-Lam/go-wechat/caches"
+	"github.com/Xavier-Lam/go-wechat/client"
+	"github.com/Xavier-Lam/go-wechat/wechatotel"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type httpClientFunc func(req *http.Request) (*http.Response, error)
+
+func (f httpClientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestObserver(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	obs, err := wechatotel.NewObserver(tp, mp)
+	assert.NoError(t, err)
+
+	calls := 0
+	hc := httpClientFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		recorder := httptest.NewRecorder()
+		recorder.Header().Set("Content-Type", "application/json")
+		if calls == 1 {
+			recorder.WriteString(`{"access_token": "token", "expires_in": 7200}`)
+		} else {
+			recorder.WriteString(`{"errcode": 45009, "errmsg": "api freq out of limit"}`)
+		}
+		return recorder.Result(), nil
+	})
+
+	c := client.New(wechat.NewAuth("app-id", "app-secret"), client.Config{
+		Cache:      caches.NewDummyCache(),
+		HttpClient: hc,
+		Observer:   obs,
+	})
+	_, err = c.Get("/cgi-bin/user/info", true)
+	assert.ErrorIs(t, err, client.ErrQuotaExceeded)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, client.SpanTokenFetch, spans[0].Name)
+	assert.Equal(t, client.SpanHttpAttempt, spans[1].Name)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+
+	rm := metricdata.ResourceMetrics{}
+	err = reader.Collect(context.Background(), &rm)
+	assert.NoError(t, err)
+
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	assert.Contains(t, metrics, client.MetricTokenCacheMiss)
+	assert.Contains(t, metrics, client.MetricTokenFetch)
+	assert.Contains(t, metrics, client.MetricHttpDuration)
+
+	result := metrics[client.MetricApiResult].(metricdata.Sum[int64])
+	assert.Len(t, result.DataPoints, 1)
+	assert.Equal(t, int64(1), result.DataPoints[0].Value)
+	errcode, _ := result.DataPoints[0].Attributes.Value(client.AttrErrCode)
+	assert.Equal(t, int64(45009), errcode.AsInt64())
+}